@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/packwiz/packwiz/core"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// deltaCmd represents the delta command
+var deltaCmd = &cobra.Command{
+	Use:   "delta [previous index]",
+	Short: "Export an archive of the files that have changed since a previous version of the index",
+	Long: `Export an archive containing the pack and index files, and only the files that have been added or changed since
+the given previous version of the index, so that clients can be updated by downloading minimal data`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Fprintln(core.Output, "Loading modpack...")
+		pack, err := core.LoadPack()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		index, err := pack.LoadIndex()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		// Do a refresh to ensure files are up to date
+		err = index.Refresh()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		err = index.Write()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		err = pack.UpdateIndexHash()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		err = pack.Write()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		previousIndex, err := core.LoadIndex(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load previous index: %s\n", err)
+			os.Exit(1)
+		}
+
+		fileName := viper.GetString("delta.output")
+		if fileName == "" {
+			fileName = pack.GetPackName() + "-delta.zip"
+		}
+		expFile, err := os.Create(fileName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create zip: %s\n", err.Error())
+			os.Exit(1)
+		}
+		exp := core.NewExportZip(expFile)
+
+		packFile := viper.GetString("pack-file")
+		packDir := filepath.Dir(packFile)
+		indexPath := filepath.Join(packDir, filepath.FromSlash(pack.Index.File))
+		for _, v := range []string{packFile, indexPath} {
+			err = addFileToZip(exp, packDir, v)
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintf(os.Stderr, "Error adding %s: %s\n", v, err.Error())
+				os.Exit(1)
+			}
+		}
+
+		changed, removed := diffIndex(previousIndex, index)
+		for _, v := range changed {
+			path, err := filepath.Rel(packDir, index.GetFilePath(v))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error resolving file: %s\n", err.Error())
+				continue
+			}
+			file, err := exp.Create(filepath.ToSlash(path))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating file: %s\n", err.Error())
+				continue
+			}
+			err = index.SaveFile(v, file)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error copying file: %s\n", err.Error())
+				continue
+			}
+		}
+
+		err = exp.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing export file: "+err.Error())
+			os.Exit(1)
+		}
+		err = expFile.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing export file: "+err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(core.Output, "Delta exported to %s (%d files changed, %d files removed)\n", fileName, len(changed), removed)
+	},
+}
+
+// diffIndex returns the files in the current index that are new or have changed since the previous index, and the
+// number of files that have been removed
+func diffIndex(previous core.Index, current core.Index) ([]core.IndexFile, int) {
+	type fileKey struct {
+		file  string
+		alias string
+	}
+	hashFormat := func(in core.Index, f core.IndexFile) string {
+		if f.HashFormat != "" {
+			return f.HashFormat
+		}
+		return in.HashFormat
+	}
+
+	previousFiles := make(map[fileKey]core.IndexFile)
+	for _, v := range previous.Files {
+		previousFiles[fileKey{v.File, v.Alias}] = v
+	}
+
+	var changed []core.IndexFile
+	saved := make(map[string]bool)
+	for _, v := range current.Files {
+		key := fileKey{v.File, v.Alias}
+		old, ok := previousFiles[key]
+		delete(previousFiles, key)
+		if ok && old.Hash == v.Hash && hashFormat(previous, old) == hashFormat(current, v) && v.Hash != "" {
+			continue
+		}
+		// Aliased files are stored once, under their original path
+		if !saved[v.File] {
+			saved[v.File] = true
+			changed = append(changed, v)
+		}
+	}
+	return changed, len(previousFiles)
+}
+
+func addFileToZip(exp *core.ExportZip, baseDir string, path string) error {
+	relPath, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := exp.Create(filepath.ToSlash(relPath))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dest, src)
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(deltaCmd)
+
+	deltaCmd.Flags().StringP("output", "o", "", "The file to export the delta archive to")
+	_ = viper.BindPFlag("delta.output", deltaCmd.Flags().Lookup("output"))
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+// runCommand runs packwiz with the given arguments
+func runCommand(t *testing.T, args ...string) {
+	t.Helper()
+	oldOutput := core.Output
+	rootCmd.SetArgs(args)
+	defer func() {
+		rootCmd.SetArgs(nil)
+		quiet = false
+		core.Output = oldOutput
+	}()
+	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(path string, content string) {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("pack.toml", "name = \"Test Pack\"\nversion = \"1.0.0\"\npack-format = \"packwiz:1.0.0\"\n\n[index]\nfile = \"index.toml\"\n"+
+		"hash-format = \"sha256\"\n\n[versions]\nminecraft = \"1.18.2\"\n")
+	writeFile("index.toml", "hash-format = \"sha256\"\n")
+	writeFile("config/unchanged.cfg", "unchanged")
+	writeFile("config/changed.cfg", "old contents")
+	writeFile("config/removed.cfg", "removed")
+	packFile := filepath.Join(dir, "pack.toml")
+	runCommand(t, "refresh", "--quiet", "--pack-file", packFile)
+
+	previousIndex, err := ioutil.ReadFile(filepath.Join(dir, "index.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousIndexFile := filepath.Join(t.TempDir(), "index.toml")
+	if err := ioutil.WriteFile(previousIndexFile, previousIndex, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile("config/changed.cfg", "new contents")
+	writeFile("config/added.cfg", "added")
+	if err := os.Remove(filepath.Join(dir, "config", "removed.cfg")); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "delta.zip")
+	runCommand(t, "delta", previousIndexFile, "--quiet", "--pack-file", packFile, "--output", output)
+
+	zr, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	want := []string{"config/added.cfg", "config/changed.cfg", "index.toml", "pack.toml"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("delta archive contains %v, want %v", names, want)
+	}
+}
@@ -27,10 +27,10 @@ var initCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		_, err := os.Stat(viper.GetString("pack-file"))
 		if err == nil && !viper.GetBool("init.reinit") {
-			fmt.Println("Modpack metadata file already exists, use -r to override!")
+			fmt.Fprintln(os.Stderr, "Modpack metadata file already exists, use -r to override!")
 			os.Exit(1)
 		} else if err != nil && !os.IsNotExist(err) {
-			fmt.Printf("Error checking pack file: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error checking pack file: %s\n", err)
 			os.Exit(1)
 		}
 
@@ -63,7 +63,7 @@ var initCmd = &cobra.Command{
 
 		mcVersions, err := getValidMCVersions()
 		if err != nil {
-			fmt.Printf("Failed to get latest minecraft versions: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to get latest minecraft versions: %s\n", err)
 			os.Exit(1)
 		}
 
@@ -94,7 +94,7 @@ var initCmd = &cobra.Command{
 			if ok {
 				versions, latestVersion, err := loader.VersionListGetter(mcVersion)
 				if err != nil {
-					fmt.Printf("Error loading versions: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error loading versions: %s\n", err)
 					os.Exit(1)
 				}
 				componentVersion := viper.GetString("init." + loader.Name + "-version")
@@ -113,20 +113,20 @@ var initCmd = &cobra.Command{
 					}
 				}
 				if !found {
-					fmt.Println("Given " + loader.FriendlyName + " version cannot be found!")
+					fmt.Fprintln(os.Stderr, "Given "+loader.FriendlyName+" version cannot be found!")
 					os.Exit(1)
 				}
 				modLoaderVersions[loader.Name] = componentVersion
 			} else {
-				fmt.Println("Given mod loader is not supported! Use \"none\" to specify no modloader, or to configure one manually.")
-				fmt.Print("The following mod loaders are supported: ")
+				fmt.Fprintln(os.Stderr, "Given mod loader is not supported! Use \"none\" to specify no modloader, or to configure one manually.")
+				fmt.Fprint(os.Stderr, "The following mod loaders are supported: ")
 				keys := make([]string, len(core.ModLoaders))
 				i := 0
 				for k := range core.ModLoaders {
 					keys[i] = k
 					i++
 				}
-				fmt.Println(strings.Join(keys, ", "))
+				fmt.Fprintln(os.Stderr, strings.Join(keys, ", "))
 				os.Exit(1)
 			}
 		}
@@ -137,12 +137,12 @@ var initCmd = &cobra.Command{
 			// Create file
 			err = ioutil.WriteFile(indexFilePath, []byte{}, 0644)
 			if err != nil {
-				fmt.Printf("Error creating index file: %s\n", err)
+				fmt.Fprintf(os.Stderr, "Error creating index file: %s\n", err)
 				os.Exit(1)
 			}
-			fmt.Println(indexFilePath + " created!")
+			fmt.Fprintln(core.Output, indexFilePath+" created!")
 		} else if err != nil {
-			fmt.Printf("Error checking index file: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error checking index file: %s\n", err)
 			os.Exit(1)
 		}
 
@@ -172,30 +172,30 @@ var initCmd = &cobra.Command{
 		// Refresh the index and pack
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = index.Refresh()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = index.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.UpdateIndexHash()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
-		fmt.Println(viper.GetString("pack-file") + " created!")
+		fmt.Fprintln(core.Output, viper.GetString("pack-file")+" created!")
 	},
 }
 
@@ -228,10 +228,10 @@ func init() {
 }
 
 func initReadValue(prompt string, def string) string {
-	fmt.Print(prompt)
+	fmt.Fprint(core.PromptOutput, prompt)
 	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
 	if err != nil {
-		fmt.Printf("Error reading input: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading input: %s\n", err)
 		os.Exit(1)
 	}
 	// Trims both CR and LF
@@ -262,7 +262,7 @@ func (m mcVersionManifest) checkValid(version string) {
 			return
 		}
 	}
-	fmt.Println("Given version is not a valid Minecraft version!")
+	fmt.Fprintln(os.Stderr, "Given version is not a valid Minecraft version!")
 	os.Exit(1)
 }
 
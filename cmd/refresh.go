@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/spf13/viper"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/packwiz/packwiz/core"
 	"github.com/spf13/cobra"
@@ -15,49 +20,247 @@ var refreshCmd = &cobra.Command{
 	Short: "Refresh the index file",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Loading modpack...")
+		fmt.Fprintln(core.Output, "Loading modpack...")
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		build, err := cmd.Flags().GetBool("build")
 		if err == nil && build {
 			viper.Set("no-internal-hashes", false)
 		} else if viper.GetBool("no-internal-hashes") {
-			fmt.Println("Note: no-internal-hashes mode is set, no hashes will be saved. Use --build to override this for distribution.")
+			fmt.Fprintln(core.Output, "Note: no-internal-hashes mode is set, no hashes will be saved. Use --build to override this for distribution.")
 		}
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
+		err = migrateMetadata(cmd.Context(), index)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		err = renderURLTemplates(index)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		reportModStatus(index)
 		err = index.Refresh()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = index.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
+		if indexHashFormat, _ := cmd.Flags().GetString("index-hash-format"); indexHashFormat != "" {
+			err = pack.SetIndexHashFormat(indexHashFormat)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
 		err = pack.UpdateIndexHash()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
-		fmt.Println("Index refreshed!")
+		fmt.Fprintln(core.Output, "Index refreshed!")
+
+		if viper.GetBool("refresh.verify-remote") {
+			fmt.Fprintln(core.Output, "Checking download URLs...")
+			dead, err := verifyRemoteURLs(cmd.Context(), index)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if len(dead) > 0 {
+				fmt.Fprintf(os.Stderr, "Found %d unreachable download URLs:\n", len(dead))
+				for _, v := range dead {
+					fmt.Fprintln(os.Stderr, v)
+				}
+				os.Exit(1)
+			}
+			fmt.Fprintln(core.Output, "All download URLs are reachable!")
+		}
 	},
 }
 
+// migrateMetadata upgrades outdated mod metadata, for updaters that support it
+func migrateMetadata(ctx context.Context, index core.Index) error {
+	updaterMap := make(map[string][]*core.Mod)
+	for _, v := range index.GetAllMods() {
+		modData, err := core.LoadMod(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading mod file: %s\n", err.Error())
+			continue
+		}
+		for k := range modData.Update {
+			if _, ok := core.Updaters[k].(core.MetadataMigrator); ok {
+				updaterMap[k] = append(updaterMap[k], &modData)
+				break
+			}
+		}
+	}
+
+	for k, v := range updaterMap {
+		changed, err := core.Updaters[k].(core.MetadataMigrator).MigrateMetadata(ctx, v)
+		if err != nil {
+			// Migrating may need network access, but refreshing must still work offline
+			fmt.Fprintf(os.Stderr, "Warning: failed to migrate metadata for %s, it will be retried on the next refresh: %s\n", k, err)
+			continue
+		}
+		for i, modData := range v {
+			if !changed[i] {
+				continue
+			}
+			_, _, err = modData.Write()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(core.Output, "Migrated metadata for \"%s\"\n", modData.Name)
+		}
+	}
+	return nil
+}
+
+// reportModStatus prints warnings for mods that updaters have flagged in their metadata
+func reportModStatus(index core.Index) {
+	for _, v := range index.GetAllMods() {
+		modData, err := core.LoadMod(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading mod file: %s\n", err.Error())
+			continue
+		}
+		for k := range modData.Update {
+			reporter, ok := core.Updaters[k].(core.StatusReporter)
+			if !ok {
+				continue
+			}
+			if status := reporter.GetStatus(modData); status != "" {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", modData.Name, status)
+			}
+		}
+	}
+}
+
+// renderURLTemplates updates the download URLs of mods that use URL templates
+func renderURLTemplates(index core.Index) error {
+	for _, v := range index.GetAllMods() {
+		modData, err := core.LoadMod(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading mod file: %s\n", err.Error())
+			continue
+		}
+		changed, err := modData.RenderURLTemplate()
+		if err != nil {
+			return fmt.Errorf("failed to update download URL for \"%s\": %w", modData.Name, err)
+		}
+		if !changed {
+			continue
+		}
+		_, _, err = modData.Write()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(core.Output, "Updated download URL for \"%s\"\n", modData.Name)
+	}
+	return nil
+}
+
+// verifyRemoteDelay is the time to wait between requests when verifying download URLs, to avoid being rate limited
+const verifyRemoteDelay = 100 * time.Millisecond
+
+// verifyRemoteURLs checks that the download URL of every mod can be reached, returning a description of each one that
+// can't be
+func verifyRemoteURLs(ctx context.Context, index core.Index) ([]string, error) {
+	var dead []string
+	checked := make(map[string]bool)
+	for _, v := range index.GetAllMods() {
+		modData, err := core.LoadMod(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading mod file: %s\n", err.Error())
+			continue
+		}
+		u := modData.Download.URL
+		if u == "" {
+			dead = append(dead, fmt.Sprintf("%s: no download URL", modData.Name))
+			continue
+		}
+		if checked[u] {
+			continue
+		}
+		checked[u] = true
+
+		select {
+		case <-time.After(verifyRemoteDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		err = checkURL(ctx, u)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			dead = append(dead, fmt.Sprintf("%s (%s): %s", modData.Name, u, err))
+		}
+	}
+	return dead, nil
+}
+
+// checkURL sends a HEAD request to a URL, falling back to GET if the server doesn't support HEAD, and waiting if the
+// server asks us to slow down
+func checkURL(ctx context.Context, u string) error {
+	method := "HEAD"
+	for attempt := 0; attempt < 3; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusMethodNotAllowed && method == "HEAD":
+			method = "GET"
+			continue
+		case resp.StatusCode == http.StatusTooManyRequests:
+			delay := 5 * time.Second
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				delay = time.Duration(secs) * time.Second
+			}
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		case resp.StatusCode >= 400:
+			return errors.New(resp.Status)
+		}
+		return nil
+	}
+	return errors.New("too many attempts")
+}
+
 func init() {
 	rootCmd.AddCommand(refreshCmd)
 
 	refreshCmd.Flags().Bool("build", false, "Only has an effect in no-internal-hashes mode: generates internal hashes for distribution with packwiz-installer")
+	refreshCmd.Flags().String("index-hash-format", "", "Set the format of the hash of the index file (sha256 or sha512), which is saved in the pack file")
+	refreshCmd.Flags().Bool("verify-remote", false, "Check that the download URL of every mod is reachable")
+	_ = viper.BindPFlag("refresh.verify-remote", refreshCmd.Flags().Lookup("verify-remote"))
 }
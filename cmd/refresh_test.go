@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+// migrateTestUpdater replaces murmur2 hashes with SHA1 hashes from a fixed list, keyed by mod name
+type migrateTestUpdater struct {
+	sha1Hashes map[string]string
+}
+
+func (u migrateTestUpdater) ParseUpdate(updateUnparsed map[string]interface{}) (interface{}, error) {
+	return updateUnparsed, nil
+}
+
+func (u migrateTestUpdater) CheckUpdate(ctx context.Context, mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+	return make([]core.UpdateCheck, len(mods)), nil
+}
+
+func (u migrateTestUpdater) DoUpdate(ctx context.Context, mods []*core.Mod, cachedState []interface{}) error {
+	return nil
+}
+
+func (u migrateTestUpdater) MigrateMetadata(ctx context.Context, mods []*core.Mod) ([]bool, error) {
+	changed := make([]bool, len(mods))
+	for i, mod := range mods {
+		if hash, ok := u.sha1Hashes[mod.Name]; ok && mod.Download.HashFormat == "murmur2" {
+			mod.Download.HashFormat = "sha1"
+			mod.Download.Hash = hash
+			changed[i] = true
+		}
+	}
+	return changed, nil
+}
+
+func TestMigrateMetadata(t *testing.T) {
+	const sha1Hash = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+	core.Updaters["migratetest"] = migrateTestUpdater{sha1Hashes: map[string]string{"Legacy": sha1Hash}}
+	defer delete(core.Updaters, "migratetest")
+
+	index := writeTestIndex(t, map[string]string{
+		"mods/legacy.pw.toml": "name = \"Legacy\"\nfilename = \"legacy.jar\"\n\n[download]\nurl = \"https://example.com/legacy.jar\"\n" +
+			"hash-format = \"murmur2\"\nhash = \"1234\"\n\n[update.migratetest]\nid = \"legacy\"\n",
+		"mods/current.pw.toml": "name = \"Current\"\nfilename = \"current.jar\"\n\n[download]\nurl = \"https://example.com/current.jar\"\n" +
+			"hash-format = \"sha256\"\nhash = \"abcd\"\n\n[update.migratetest]\nid = \"current\"\n",
+	})
+	if err := migrateMetadata(context.Background(), index); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][2]string{
+		"Legacy":  {"sha1", sha1Hash},
+		"Current": {"sha256", "abcd"},
+	}
+	for _, path := range index.GetAllMods() {
+		mod, err := core.LoadMod(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := [2]string{mod.Download.HashFormat, mod.Download.Hash}; got != want[mod.Name] {
+			t.Errorf("%s has a hash of %v, want %v", mod.Name, got, want[mod.Name])
+		}
+	}
+}
+
+func TestVerifyRemoteURLs(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requests[req.URL.Path]++
+		attempt := requests[req.URL.Path]
+		mu.Unlock()
+		switch req.URL.Path {
+		case "/ok.jar":
+		case "/get-only.jar":
+			if req.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/rate-limited.jar":
+			if attempt == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	modMetadata := func(name string, url string) string {
+		return "name = \"" + name + "\"\nfilename = \"" + name + ".jar\"\n\n[download]\nurl = \"" + url + "\"\nhash-format = \"sha256\"\nhash = \"abcd\"\n"
+	}
+	index := writeTestIndex(t, map[string]string{
+		"mods/ok.pw.toml":           modMetadata("OK", server.URL+"/ok.jar"),
+		"mods/get-only.pw.toml":     modMetadata("GetOnly", server.URL+"/get-only.jar"),
+		"mods/rate-limited.pw.toml": modMetadata("RateLimited", server.URL+"/rate-limited.jar"),
+		"mods/dead.pw.toml":         modMetadata("Dead", server.URL+"/dead.jar"),
+		"mods/dead-copy.pw.toml":    modMetadata("DeadCopy", server.URL+"/dead.jar"),
+		"mods/no-url.pw.toml":       modMetadata("NoURL", ""),
+	})
+
+	dead, err := verifyRemoteURLs(context.Background(), index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mods with the same URL are only checked once, so the dead URL is reported for one of them
+	if len(dead) != 2 {
+		t.Fatalf("verifyRemoteURLs() = %q, want the dead URL and the missing URL", dead)
+	}
+	sort.Strings(dead)
+	if dead[1] != "NoURL: no download URL" {
+		t.Errorf("verifyRemoteURLs() didn't report the missing URL: %q", dead)
+	}
+	wantDead := []string{"Dead (" + server.URL + "/dead.jar): 404 Not Found", "DeadCopy (" + server.URL + "/dead.jar): 404 Not Found"}
+	if dead[0] != wantDead[0] && dead[0] != wantDead[1] {
+		t.Errorf("verifyRemoteURLs() didn't report the 404: %q", dead)
+	}
+	wantRequests := map[string]int{"/ok.jar": 1, "/get-only.jar": 2, "/rate-limited.jar": 2, "/dead.jar": 1}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("verifyRemoteURLs() sent %v requests, want %v", requests, wantRequests)
+	}
+}
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
 
+	"github.com/packwiz/packwiz/core"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -12,6 +16,7 @@ import (
 var packFile string
 var modsFolder string
 var cfgFile string
+var quiet bool
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -21,7 +26,19 @@ var rootCmd = &cobra.Command{
 
 // Execute starts the root command for packwiz
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Cancel in-flight requests when interrupted; as not every command checks the context (e.g. serve), exit rather
+	// than waiting for the command to return
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+		fmt.Fprintln(os.Stderr, "Cancelled!")
+		os.Exit(1)
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		os.Exit(1)
 	}
 }
@@ -32,7 +49,7 @@ func Add(newCommand *cobra.Command) {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initQuiet, initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&packFile, "pack-file", "pack.toml", "The modpack metadata file to use")
 	_ = viper.BindPFlag("pack-file", rootCmd.PersistentFlags().Lookup("pack-file"))
@@ -42,11 +59,23 @@ func init() {
 
 	file, err := os.UserConfigDir()
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 	file = filepath.Join(file, "packwiz", ".packwiz.toml")
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "The config file to use (default \""+file+"\")")
+
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Don't reuse API responses cached earlier in this run; always query the API again")
+	_ = viper.BindPFlag("no-cache", rootCmd.PersistentFlags().Lookup("no-cache"))
+
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Don't print anything other than errors; the exit code indicates success or failure")
+}
+
+// initQuiet discards progress messages if --quiet is set; errors are written to standard error, so they are still shown
+func initQuiet() {
+	if quiet {
+		core.Output = ioutil.Discard
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -57,7 +86,7 @@ func initConfig() {
 	} else {
 		dir, err := os.UserConfigDir()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
@@ -69,6 +98,6 @@ func initConfig() {
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(core.Output, "Using config file:", viper.ConfigFileUsed())
 	}
 }
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+// writeTestIndex writes the given files (keyed by path relative to the index) to a new pack directory, and loads an
+// index with an entry for each of them; .pw.toml files are added as metadata files
+func writeTestIndex(t *testing.T, files map[string]string) core.Index {
+	t.Helper()
+	dir := t.TempDir()
+	indexData := "hash-format = \"sha256\"\n"
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		indexData += "\n[[files]]\nfile = \"" + path + "\"\nhash = \"\"\n"
+		if strings.HasSuffix(path, core.ModExtension) {
+			indexData += "metafile = true\n"
+		}
+	}
+	indexFile := filepath.Join(dir, "index.toml")
+	if err := ioutil.WriteFile(indexFile, []byte(indexData), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index, err := core.LoadIndex(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+// captureOutput runs f, returning what it writes to standard output and standard error
+func captureOutput(t *testing.T, f func()) (string, string) {
+	t.Helper()
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	stdoutRead, stdoutWrite, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderrRead, stderrWrite, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = stdoutWrite, stderrWrite
+	stdoutCh := make(chan string)
+	stderrCh := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(stdoutRead)
+		stdoutCh <- string(data)
+	}()
+	go func() {
+		data, _ := ioutil.ReadAll(stderrRead)
+		stderrCh <- string(data)
+	}()
+
+	f()
+
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	_ = stdoutWrite.Close()
+	_ = stderrWrite.Close()
+	return <-stdoutCh, <-stderrCh
+}
+
+func TestQuietOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("mod file contents"))
+	}))
+	defer server.Close()
+	defer func() {
+		quiet = false
+		core.Output = os.Stdout
+	}()
+
+	for _, quietFlag := range []bool{false, true} {
+		index := writeTestIndex(t, map[string]string{
+			"mods/templated.pw.toml": "name = \"Templated\"\nfilename = \"templated.jar\"\n\n[download]\nurl = \"" + server.URL +
+				"/1.0/templated.jar\"\nhash-format = \"sha256\"\nhash = \"old\"\nurl-template = \"" + server.URL +
+				"/{version}/{filename}\"\n\n[download.variables]\nversion = \"2.0\"\n",
+			"mods/broken.pw.toml": "name = ",
+		})
+		stdout, stderr := captureOutput(t, func() {
+			core.Output = os.Stdout
+			quiet = quietFlag
+			initQuiet()
+			if err := renderURLTemplates(index); err != nil {
+				t.Error(err)
+			}
+		})
+
+		if !strings.Contains(stderr, "Error reading mod file") {
+			t.Errorf("error wasn't printed to standard error (quiet: %v): %q", quietFlag, stderr)
+		}
+		if quietFlag && stdout != "" {
+			t.Errorf("printed to standard output with --quiet: %q", stdout)
+		}
+		if !quietFlag && !strings.Contains(stdout, "Updated download URL for \"Templated\"") {
+			t.Errorf("progress wasn't printed to standard output: %q", stdout)
+		}
+	}
+}
@@ -29,15 +29,15 @@ var serveCmd = &cobra.Command{
 		if viper.GetBool("serve.basic") {
 			http.Handle("/", http.FileServer(http.Dir(".")))
 		} else {
-			fmt.Println("Loading modpack...")
+			fmt.Fprintln(core.Output, "Loading modpack...")
 			pack, err := core.LoadPack()
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			index, err := pack.LoadIndex()
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			indexPath := filepath.Join(filepath.Dir(viper.GetString("pack-file")), filepath.FromSlash(pack.Index.File))
@@ -47,7 +47,7 @@ var serveCmd = &cobra.Command{
 				urlPath := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(req.URL.Path, "/")), "/")
 				indexRelPath, err := filepath.Rel(indexDir, filepath.FromSlash(urlPath))
 				if err != nil {
-					fmt.Println(err)
+					fmt.Fprintln(os.Stderr, err)
 					return
 				}
 				indexRelPathSlash := path.Clean(filepath.ToSlash(indexRelPath))
@@ -67,35 +67,35 @@ var serveCmd = &cobra.Command{
 						// Reload pack and index (might have changed on disk)
 						pack, err = core.LoadPack()
 						if err != nil {
-							fmt.Println(err)
+							fmt.Fprintln(os.Stderr, err)
 							return
 						}
 						index, err = pack.LoadIndex()
 						if err != nil {
-							fmt.Println(err)
+							fmt.Fprintln(os.Stderr, err)
 							return
 						}
 						err = index.Refresh()
 						if err != nil {
-							fmt.Println(err)
+							fmt.Fprintln(os.Stderr, err)
 							return
 						}
 						err = index.Write()
 						if err != nil {
-							fmt.Println(err)
+							fmt.Fprintln(os.Stderr, err)
 							return
 						}
 						err = pack.UpdateIndexHash()
 						if err != nil {
-							fmt.Println(err)
+							fmt.Fprintln(os.Stderr, err)
 							return
 						}
 						err = pack.Write()
 						if err != nil {
-							fmt.Println(err)
+							fmt.Fprintln(os.Stderr, err)
 							return
 						}
-						fmt.Println("Index refreshed!")
+						fmt.Fprintln(core.Output, "Index refreshed!")
 
 						// Downgrade to a read lock
 						refreshMutex.Unlock()
@@ -119,7 +119,7 @@ var serveCmd = &cobra.Command{
 				if found {
 					f, err := os.Open(destPath)
 					if err != nil {
-						fmt.Printf("Error reading file \"%s\": %s\n", destPath, err)
+						fmt.Fprintf(os.Stderr, "Error reading file \"%s\": %s\n", destPath, err)
 						w.WriteHeader(404)
 						_, _ = w.Write([]byte("File not found"))
 						return
@@ -130,13 +130,13 @@ var serveCmd = &cobra.Command{
 						err = err2
 					}
 					if err != nil {
-						fmt.Printf("Error reading file \"%s\": %s\n", destPath, err)
+						fmt.Fprintf(os.Stderr, "Error reading file \"%s\": %s\n", destPath, err)
 						w.WriteHeader(500)
 						_, _ = w.Write([]byte("Failed to read file"))
 						return
 					}
 				} else {
-					fmt.Printf("File not found: %s\n", destPath)
+					fmt.Fprintf(os.Stderr, "File not found: %s\n", destPath)
 					w.WriteHeader(404)
 					_, _ = w.Write([]byte("File not found"))
 					return
@@ -145,10 +145,10 @@ var serveCmd = &cobra.Command{
 		}
 
 		port := strconv.Itoa(viper.GetInt("serve.port"))
-		fmt.Println("Running on port " + port)
+		fmt.Fprintln(core.Output, "Running on port "+port)
 		err := http.ListenAndServe(":"+port, nil)
 		if err != nil {
-			fmt.Printf("Error running server: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error running server: %s\n", err)
 			os.Exit(1)
 		}
 	},
@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"github.com/spf13/viper"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/packwiz/packwiz/core"
@@ -21,31 +23,43 @@ var updateCmd = &cobra.Command{
 		// TODO: --check flag?
 		// TODO: specify multiple mods to update at once?
 
-		fmt.Println("Loading modpack...")
+		fmt.Fprintln(core.Output, "Loading modpack...")
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		mcVersion, err := pack.GetMCVersion()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
+		// Dependencies required before updating, so that dependencies that are no longer required can be removed
+		var requiredBefore map[string]bool
+		if viper.GetBool("update.prune-deps") {
+			_, requiredBefore, err = getDependencyInfo(cmd.Context(), index)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
 		var singleUpdatedName string
+		// Mods that fail to update are skipped, so that the other updates are still saved
+		updateFailed := false
 		if viper.GetBool("update.all") {
 			updaterMap := make(map[string][]core.Mod)
-			fmt.Println("Reading mod files...")
+			fmt.Fprintln(core.Output, "Reading mod files...")
 			for _, v := range index.GetAllMods() {
 				modData, err := core.LoadMod(v)
 				if err != nil {
-					fmt.Printf("Error reading mod file: %s\n", err.Error())
+					fmt.Fprintf(os.Stderr, "Error reading mod file: %s\n", err.Error())
 					continue
 				}
 
@@ -63,90 +77,103 @@ var updateCmd = &cobra.Command{
 					updaterMap[k] = append(slice, modData)
 				}
 				if !updaterFound {
-					fmt.Printf("A supported update system for \"%s\" cannot be found.\n", modData.Name)
+					fmt.Fprintf(os.Stderr, "A supported update system for \"%s\" cannot be found.\n", modData.Name)
 				}
 			}
 
-			fmt.Println("Checking for updates...")
+			fmt.Fprintln(core.Output, "Checking for updates...")
 			updatesFound := false
 			updaterPointerMap := make(map[string][]*core.Mod)
+			oldPathsMap := make(map[*core.Mod][]string)
 			updaterCachedStateMap := make(map[string][]interface{})
 			for k, v := range updaterMap {
-				checks, err := core.Updaters[k].CheckUpdate(v, mcVersion, pack)
+				checks, err := core.Updaters[k].CheckUpdate(cmd.Context(), v, mcVersion, pack)
 				if err != nil {
 					// TODO: do we return err code 1?
-					fmt.Printf("Failed to check updates for %s: %s\n", k, err.Error())
+					fmt.Fprintf(os.Stderr, "Failed to check updates for %s: %s\n", k, err.Error())
 					continue
 				}
 				for i, check := range checks {
+					if check.Warning != "" {
+						fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", v[i].Name, check.Warning)
+					}
 					if check.Error != nil {
 						// TODO: do we return err code 1?
-						fmt.Printf("Failed to check updates for %s: %s\n", v[i].Name, check.Error.Error())
+						fmt.Fprintf(os.Stderr, "Failed to check updates for %s: %s\n", v[i].Name, check.Error.Error())
 						continue
 					}
 					if check.UpdateAvailable {
 						if !updatesFound {
-							fmt.Println("Updates found:")
+							fmt.Fprintln(core.Output, "Updates found:")
 							updatesFound = true
 						}
-						fmt.Printf("%s: %s\n", v[i].Name, check.UpdateString)
+						fmt.Fprintf(core.Output, "%s: %s\n", v[i].Name, check.UpdateString)
 						updaterPointerMap[k] = append(updaterPointerMap[k], &v[i])
+						oldPathsMap[&v[i]] = index.GetModDestPaths(v[i])
 						updaterCachedStateMap[k] = append(updaterCachedStateMap[k], check.CachedState)
 					}
 				}
 			}
 
 			if !updatesFound {
-				fmt.Println("All mods are up to date!")
+				fmt.Fprintln(core.Output, "All mods are up to date!")
 				return
 			}
 
-			fmt.Print("Do you want to update? [Y/n]: ")
+			fmt.Fprint(core.PromptOutput, "Do you want to update? [Y/n]: ")
 			answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
 			ansNormal := strings.ToLower(strings.TrimSpace(answer))
 			if len(ansNormal) > 0 && ansNormal[0] == 'n' {
-				fmt.Println("Cancelled!")
+				fmt.Fprintln(core.Output, "Cancelled!")
 				return
 			}
 
 			for k, v := range updaterPointerMap {
-				err := core.Updaters[k].DoUpdate(v, updaterCachedStateMap[k])
+				err := core.Updaters[k].DoUpdate(cmd.Context(), v, updaterCachedStateMap[k])
 				if err != nil {
-					// TODO: do we return err code 1?
-					fmt.Println(err.Error())
+					fmt.Fprintln(os.Stderr, err)
+					updateFailed = true
 					continue
 				}
 				for _, modData := range v {
 					format, hash, err := modData.Write()
 					if err != nil {
-						fmt.Println(err.Error())
+						fmt.Fprintln(os.Stderr, err)
+						updateFailed = true
 						continue
 					}
 					err = index.RefreshFileWithHash(modData.GetFilePath(), format, hash, true)
 					if err != nil {
-						fmt.Println(err.Error())
+						fmt.Fprintln(os.Stderr, err)
+						updateFailed = true
+						continue
+					}
+					err = cleanupRenamedFiles(&index, oldPathsMap[modData], *modData)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, err)
+						updateFailed = true
 						continue
 					}
 				}
 			}
 		} else {
 			if len(args) < 1 || len(args[0]) == 0 {
-				fmt.Println("Must specify a valid mod, or use the --all flag!")
+				fmt.Fprintln(os.Stderr, "Must specify a valid mod, or use the --all flag!")
 				os.Exit(1)
 			}
 			modPath, ok := index.FindMod(args[0])
 			if !ok {
-				fmt.Println("You don't have this mod installed.")
+				fmt.Fprintln(os.Stderr, "You don't have this mod installed.")
 				os.Exit(1)
 			}
 			modData, err := core.LoadMod(modPath)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 			singleUpdatedName = modData.Name
@@ -158,37 +185,46 @@ var updateCmd = &cobra.Command{
 				}
 				updaterFound = true
 
-				check, err := updater.CheckUpdate([]core.Mod{modData}, mcVersion, pack)
+				check, err := updater.CheckUpdate(cmd.Context(), []core.Mod{modData}, mcVersion, pack)
 				if err != nil {
-					fmt.Println(err)
+					fmt.Fprintln(os.Stderr, err)
 					os.Exit(1)
 				}
 				if len(check) != 1 {
-					fmt.Println("Invalid update check response")
+					fmt.Fprintln(os.Stderr, "Invalid update check response")
 					os.Exit(1)
 				}
+				if check[0].Warning != "" {
+					fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", modData.Name, check[0].Warning)
+				}
 
 				if check[0].UpdateAvailable {
-					fmt.Printf("Update available: %s\n", check[0].UpdateString)
+					fmt.Fprintf(core.Output, "Update available: %s\n", check[0].UpdateString)
+					oldPaths := index.GetModDestPaths(modData)
 
-					err = updater.DoUpdate([]*core.Mod{&modData}, []interface{}{check[0].CachedState})
+					err = updater.DoUpdate(cmd.Context(), []*core.Mod{&modData}, []interface{}{check[0].CachedState})
 					if err != nil {
-						fmt.Println(err)
+						fmt.Fprintln(os.Stderr, err)
 						os.Exit(1)
 					}
 
 					format, hash, err := modData.Write()
 					if err != nil {
-						fmt.Println(err)
+						fmt.Fprintln(os.Stderr, err)
 						os.Exit(1)
 					}
 					err = index.RefreshFileWithHash(modPath, format, hash, true)
 					if err != nil {
-						fmt.Println(err)
+						fmt.Fprintln(os.Stderr, err)
+						os.Exit(1)
+					}
+					err = cleanupRenamedFiles(&index, oldPaths, modData)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, err)
 						os.Exit(1)
 					}
 				} else {
-					fmt.Printf("\"%s\" is already up to date!\n", modData.Name)
+					fmt.Fprintf(core.Output, "\"%s\" is already up to date!\n", modData.Name)
 					return
 				}
 
@@ -196,37 +232,175 @@ var updateCmd = &cobra.Command{
 			}
 			if !updaterFound {
 				// TODO: use file name instead of Name when len(Name) == 0 in all places?
-				fmt.Println("A supported update system for \"" + modData.Name + "\" cannot be found.")
+				fmt.Fprintln(os.Stderr, "A supported update system for \""+modData.Name+"\" cannot be found.")
+				os.Exit(1)
+			}
+		}
+
+		if requiredBefore != nil {
+			err = pruneDependencies(cmd.Context(), &index, requiredBefore)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 		}
 
 		err = index.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.UpdateIndexHash()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if updateFailed {
+			fmt.Fprintln(os.Stderr, "Some mods failed to update")
 			os.Exit(1)
 		}
 		if viper.GetBool("update.all") {
-			fmt.Println("Mods updated!")
+			fmt.Fprintln(core.Output, "Mods updated!")
 		} else {
-			fmt.Printf("\"%s\" updated!\n", singleUpdatedName)
+			fmt.Fprintf(core.Output, "\"%s\" updated!\n", singleUpdatedName)
 		}
 	},
 }
 
+// getDependencyInfo finds the project of each mod (by the path of its metadata file) and the set of projects required by
+// any mod, for mods with an updater that can list dependencies. Projects are identified by the updater name and ID.
+func getDependencyInfo(ctx context.Context, index core.Index) (map[string]string, map[string]bool, error) {
+	modPaths := make(map[string][]string)
+	updaterMods := make(map[string][]core.Mod)
+	for _, v := range index.GetAllMods() {
+		modData, err := core.LoadMod(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		for k := range modData.Update {
+			if _, ok := core.Updaters[k].(core.DependencyLister); ok {
+				updaterMods[k] = append(updaterMods[k], modData)
+				modPaths[k] = append(modPaths[k], v)
+				break
+			}
+		}
+	}
+
+	projects := make(map[string]string)
+	required := make(map[string]bool)
+	for k, mods := range updaterMods {
+		projectIDs, deps, err := core.Updaters[k].(core.DependencyLister).ListDependencies(ctx, mods)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find dependencies for %s: %w", k, err)
+		}
+		for i, id := range projectIDs {
+			if id != "" {
+				projects[modPaths[k][i]] = k + ":" + id
+			}
+			for _, dep := range deps[i] {
+				required[k+":"+dep] = true
+			}
+		}
+	}
+	return projects, required, nil
+}
+
+// pruneDependencies offers to remove mods that were required by other mods before updating, but no longer are
+func pruneDependencies(ctx context.Context, index *core.Index, requiredBefore map[string]bool) error {
+	fmt.Fprintln(core.Output, "Checking for dependencies that are no longer required...")
+	projects, required, err := getDependencyInfo(ctx, *index)
+	if err != nil {
+		return err
+	}
+
+	var orphaned []string
+	for modPath, project := range projects {
+		if requiredBefore[project] && !required[project] {
+			orphaned = append(orphaned, modPath)
+		}
+	}
+	if len(orphaned) == 0 {
+		return nil
+	}
+	sort.Strings(orphaned)
+
+	fmt.Fprintln(core.Output, "The following dependencies are no longer required by any mod:")
+	for _, v := range orphaned {
+		modData, err := core.LoadMod(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(core.Output, modData.Name)
+	}
+	fmt.Fprint(core.PromptOutput, "Do you want to remove them? [y/N]: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	ansNormal := strings.ToLower(strings.TrimSpace(answer))
+	if !(len(ansNormal) > 0 && ansNormal[0] == 'y') {
+		return nil
+	}
+
+	for _, v := range orphaned {
+		err = os.Remove(v)
+		if err != nil {
+			return err
+		}
+		err = index.RemoveFile(v)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(core.Output, "Removed %d dependencies\n", len(orphaned))
+	return nil
+}
+
+// cleanupRenamedFiles removes files left at a mod's old destination paths when an update changes its file name, if they
+// are tracked in the index, so that stale files aren't left in the pack
+func cleanupRenamedFiles(index *core.Index, oldPaths []string, modData core.Mod) error {
+	newPaths := make(map[string]bool)
+	for _, v := range index.GetModDestPaths(modData) {
+		newPaths[v] = true
+	}
+	for _, oldPath := range oldPaths {
+		if newPaths[oldPath] {
+			continue
+		}
+		tracked := false
+		for _, f := range index.Files {
+			if !f.MetaFile && index.GetFilePath(f) == oldPath {
+				tracked = true
+				break
+			}
+		}
+		if !tracked {
+			continue
+		}
+
+		err := os.Remove(oldPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		err = index.RemoveFile(oldPath)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(core.Output, "Removed old file %s\n", oldPath)
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(updateCmd)
 
 	updateCmd.Flags().BoolP("all", "a", false, "Update all mods")
 	_ = viper.BindPFlag("update.all", updateCmd.Flags().Lookup("all"))
+	updateCmd.Flags().Bool("prune-deps", false, "After updating, offer to remove dependencies that are no longer required by any mod")
+	_ = viper.BindPFlag("update.prune-deps", updateCmd.Flags().Lookup("prune-deps"))
 }
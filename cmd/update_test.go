@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+// renameTestUpdater always has an update available, which changes the file name of the mod
+type renameTestUpdater struct{}
+
+func (u renameTestUpdater) ParseUpdate(updateUnparsed map[string]interface{}) (interface{}, error) {
+	return updateUnparsed, nil
+}
+
+func (u renameTestUpdater) CheckUpdate(ctx context.Context, mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+	checks := make([]core.UpdateCheck, len(mods))
+	for i := range mods {
+		checks[i] = core.UpdateCheck{UpdateAvailable: true, UpdateString: "1.0 -> 2.0"}
+	}
+	return checks, nil
+}
+
+func (u renameTestUpdater) DoUpdate(ctx context.Context, mods []*core.Mod, cachedState []interface{}) error {
+	for _, mod := range mods {
+		mod.FileName = "renamed-2.0.jar"
+		mod.Download.URL = "https://example.com/renamed-2.0.jar"
+	}
+	return nil
+}
+
+func TestUpdateRenamedFile(t *testing.T) {
+	core.Updaters["renametest"] = renameTestUpdater{}
+	defer delete(core.Updaters, "renametest")
+
+	index := writeTestIndex(t, map[string]string{
+		"mods/renamed.toml": "name = \"Renamed\"\nfilename = \"renamed-1.0.jar\"\n\n[download]\nurl = \"https://example.com/renamed-1.0.jar\"\n" +
+			"hash-format = \"sha256\"\nhash = \"abcd\"\n\n[update.renametest]\nversion = \"1.0\"\n",
+		"mods/renamed-1.0.jar": "old version",
+	})
+	packDir := index.GetPackRoot()
+	packFile := filepath.Join(packDir, "pack.toml")
+	if err := ioutil.WriteFile(packFile, []byte("name = \"Test Pack\"\npack-format = \"packwiz:1.0.0\"\n\n[index]\nfile = \"index.toml\"\n"+
+		"hash-format = \"sha256\"\n\n[versions]\nminecraft = \"1.18.2\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(t, "update", "renamed", "--all=false", "--prune-deps=false", "--quiet", "--pack-file", packFile)
+
+	oldFile := filepath.Join(packDir, "mods", "renamed-1.0.jar")
+	if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
+		t.Errorf("the old file wasn't removed (stat error: %v)", err)
+	}
+	updatedIndex, err := core.LoadIndex(filepath.Join(packDir, "index.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range updatedIndex.Files {
+		if f.File == "mods/renamed-1.0.jar" {
+			t.Error("the old file is still in the index")
+		}
+	}
+	mod, err := core.LoadMod(filepath.Join(packDir, "mods", "renamed.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mod.FileName != "renamed-2.0.jar" {
+		t.Errorf("updated mod has a file name of %s, want renamed-2.0.jar", mod.FileName)
+	}
+}
+
+func TestCleanupRenamedFilesUntracked(t *testing.T) {
+	index := writeTestIndex(t, map[string]string{
+		"mods/renamed.toml": "name = \"Renamed\"\nfilename = \"renamed-2.0.jar\"\n\n[download]\nurl = \"https://example.com/renamed-2.0.jar\"\n" +
+			"hash-format = \"sha256\"\nhash = \"abcd\"\n",
+	})
+	// Files that aren't in the index (e.g. installed by packwiz-installer) are left alone
+	oldFile := filepath.Join(index.GetPackRoot(), "mods", "renamed-1.0.jar")
+	if err := ioutil.WriteFile(oldFile, []byte("old version"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mod, err := core.LoadMod(filepath.Join(index.GetPackRoot(), "mods", "renamed.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cleanupRenamedFiles(&index, []string{oldFile}, mod); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(oldFile); err != nil {
+		t.Errorf("an untracked file was removed: %v", err)
+	}
+}
+
+// promptAnswerer records prompts, answering each one by writing the next answer to standard input. Each prompt reads
+// from standard input with a new buffered reader, so answers can't be written all at once.
+type promptAnswerer struct {
+	prompts bytes.Buffer
+	answers []string
+	input   *os.File
+}
+
+func (a *promptAnswerer) Write(p []byte) (int, error) {
+	if len(a.answers) > 0 {
+		if _, err := a.input.WriteString(a.answers[0]); err != nil {
+			return 0, err
+		}
+		a.answers = a.answers[1:]
+	}
+	return a.prompts.Write(p)
+}
+
+// answerPrompts answers prompts with the given answers, in order, for the rest of the test, returning a buffer that
+// prompts are written to
+func answerPrompts(t *testing.T, answers ...string) *bytes.Buffer {
+	t.Helper()
+	inputRead, inputWrite, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	answerer := &promptAnswerer{answers: answers, input: inputWrite}
+	oldStdin, oldPromptOutput := os.Stdin, core.PromptOutput
+	os.Stdin, core.PromptOutput = inputRead, answerer
+	t.Cleanup(func() {
+		os.Stdin, core.PromptOutput = oldStdin, oldPromptOutput
+		_ = inputWrite.Close()
+		_ = inputRead.Close()
+	})
+	return &answerer.prompts
+}
+
+// dependencyTestUpdater reads the project ID and dependencies of mods from their metadata; mods marked with drop-deps have
+// an update that removes their dependencies
+type dependencyTestUpdater struct{}
+
+func (u dependencyTestUpdater) ParseUpdate(updateUnparsed map[string]interface{}) (interface{}, error) {
+	return updateUnparsed, nil
+}
+
+func (u dependencyTestUpdater) CheckUpdate(ctx context.Context, mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+	checks := make([]core.UpdateCheck, len(mods))
+	for i, mod := range mods {
+		checks[i].UpdateAvailable = mod.Update["deptest"]["drop-deps"] == true
+		checks[i].UpdateString = "1.0 -> 2.0"
+	}
+	return checks, nil
+}
+
+func (u dependencyTestUpdater) DoUpdate(ctx context.Context, mods []*core.Mod, cachedState []interface{}) error {
+	for _, mod := range mods {
+		mod.Update["deptest"]["deps"] = ""
+		delete(mod.Update["deptest"], "drop-deps")
+	}
+	return nil
+}
+
+func (u dependencyTestUpdater) ListDependencies(ctx context.Context, mods []core.Mod) ([]string, [][]string, error) {
+	ids := make([]string, len(mods))
+	deps := make([][]string, len(mods))
+	for i, mod := range mods {
+		ids[i] = fmt.Sprint(mod.Update["deptest"]["id"])
+		if modDeps := fmt.Sprint(mod.Update["deptest"]["deps"]); modDeps != "" {
+			deps[i] = strings.Split(modDeps, ",")
+		}
+	}
+	return ids, deps, nil
+}
+
+func TestUpdatePruneDependencies(t *testing.T) {
+	core.Updaters["deptest"] = dependencyTestUpdater{}
+	defer delete(core.Updaters, "deptest")
+
+	modMetadata := func(id string, deps string, dropDeps bool) string {
+		return "name = \"" + id + "\"\nfilename = \"" + id + ".jar\"\n\n[download]\nurl = \"https://example.com/" + id + ".jar\"\n" +
+			"hash-format = \"sha256\"\nhash = \"abcd\"\n\n[update.deptest]\nid = \"" + id + "\"\ndeps = \"" + deps + "\"\n" +
+			"drop-deps = " + strconv.FormatBool(dropDeps) + "\n"
+	}
+	// The update of app removes its dependency on lib, but shared is still required by other
+	index := writeTestIndex(t, map[string]string{
+		"mods/app.toml":    modMetadata("app", "lib", true),
+		"mods/lib.toml":    modMetadata("lib", "", false),
+		"mods/other.toml":  modMetadata("other", "shared", false),
+		"mods/shared.toml": modMetadata("shared", "", false),
+	})
+	packDir := index.GetPackRoot()
+	packFile := filepath.Join(packDir, "pack.toml")
+	if err := ioutil.WriteFile(packFile, []byte("name = \"Test Pack\"\npack-format = \"packwiz:1.0.0\"\n\n[index]\nfile = \"index.toml\"\n"+
+		"hash-format = \"sha256\"\n\n[versions]\nminecraft = \"1.18.2\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Accept the update, then the removal of the orphaned dependency
+	prompts := answerPrompts(t, "y\n", "y\n")
+
+	runCommand(t, "update", "--all=true", "--prune-deps=true", "--quiet", "--pack-file", packFile)
+
+	if !strings.Contains(prompts.String(), "Do you want to remove them?") {
+		t.Errorf("wasn't asked to remove the orphaned dependency: %q", prompts.String())
+	}
+	updatedIndex, err := core.LoadIndex(filepath.Join(packDir, "index.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remaining []string
+	for _, v := range updatedIndex.Files {
+		remaining = append(remaining, v.File)
+	}
+	sort.Strings(remaining)
+	want := []string{"mods/app.toml", "mods/other.toml", "mods/shared.toml"}
+	if !reflect.DeepEqual(remaining, want) {
+		t.Errorf("index contains %v after pruning, want %v", remaining, want)
+	}
+	if _, err := os.Stat(filepath.Join(packDir, "mods", "lib.toml")); !os.IsNotExist(err) {
+		t.Errorf("the orphaned dependency's metadata wasn't removed (stat error: %v)", err)
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/packwiz/packwiz/core"
+	"github.com/spf13/cobra"
+)
+
+// whatprovidesCmd represents the whatprovides command
+var whatprovidesCmd = &cobra.Command{
+	Use:   "whatprovides [file]",
+	Short: "Find which mod provides a local file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		// Sort names so the output order is consistent
+		names := make([]string, 0, len(core.FileIdentifiers))
+		for k := range core.FileIdentifiers {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		found := false
+		for _, name := range names {
+			ident, ok, err := core.FileIdentifiers[name].IdentifyFile(cmd.Context(), data)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to look up file on %s: %s\n", name, err.Error())
+				continue
+			}
+			if !ok {
+				continue
+			}
+			found = true
+			fmt.Fprintf(core.Output, "%s: %s\n", name, ident.ProjectName)
+			fmt.Fprintf(core.Output, "  URL: %s\n", ident.URL)
+			fmt.Fprintf(core.Output, "  Version: %s\n", ident.Version)
+		}
+		if !found {
+			fmt.Fprintln(os.Stderr, "No mods were found that provide this file.")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whatprovidesCmd)
+}
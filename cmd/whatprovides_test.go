@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+// fingerprintTestIdentifier identifies files whose contents match a fixed fingerprint
+type fingerprintTestIdentifier struct {
+	fingerprint []byte
+}
+
+func (i fingerprintTestIdentifier) IdentifyFile(ctx context.Context, data []byte) (core.FileIdentification, bool, error) {
+	if !bytes.Equal(data, i.fingerprint) {
+		return core.FileIdentification{}, false, nil
+	}
+	return core.FileIdentification{
+		ProjectName: "Example Mod",
+		URL:         "https://example.com/example-mod",
+		Version:     "1.0.0",
+	}, true, nil
+}
+
+func TestWhatprovides(t *testing.T) {
+	core.FileIdentifiers["fingerprinttest"] = fingerprintTestIdentifier{fingerprint: []byte("example mod jar")}
+	defer delete(core.FileIdentifiers, "fingerprinttest")
+
+	path := filepath.Join(t.TempDir(), "example-mod.jar")
+	if err := ioutil.WriteFile(path, []byte("example mod jar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	oldOutput := core.Output
+	core.Output = &out
+	defer func() { core.Output = oldOutput }()
+	whatprovidesCmd.Run(whatprovidesCmd, []string{path})
+
+	want := "fingerprinttest: Example Mod\n  URL: https://example.com/example-mod\n  Version: 1.0.0\n"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("whatprovides printed %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BOMFile is the name of the bill of materials written to exported packs
+const BOMFile = "bom.json"
+
+// BOM is a bill of materials, listing every component of an exported pack
+type BOM struct {
+	Name       string            `json:"name"`
+	Version    string            `json:"version,omitempty"`
+	Versions   map[string]string `json:"versions"`
+	Components []BOMComponent    `json:"components"`
+}
+
+// BOMComponent is a mod, resource pack or other file included in an exported pack
+type BOMComponent struct {
+	Name string `json:"name"`
+	// Type is "mod", "resourcepack" or "shaderpack" for files downloaded from metadata files (by the folder they are
+	// in, so other folders are "file"), or "override" for files included in the pack
+	Type string `json:"type"`
+	// Path is the path the file is installed to, relative to the pack root
+	Path string `json:"path"`
+	// Source is the name of the update system for the file (e.g. "curseforge"), "url" for metadata files without one,
+	// or "pack" for overrides
+	Source string `json:"source"`
+	// SourceData is the update data for the file, which identifies the project and version it is from
+	SourceData map[string]interface{} `json:"source-data,omitempty"`
+	Version    string                 `json:"version,omitempty"`
+	URL        string                 `json:"url,omitempty"`
+	HashFormat string                 `json:"hash-format"`
+	Hash       string                 `json:"hash"`
+	// Size is the size of the file in bytes, if it is known
+	Size int64  `json:"size,omitempty"`
+	Side string `json:"side,omitempty"`
+}
+
+// bomVersionKeys are the keys of update data that identify the version of a project, in order of preference
+var bomVersionKeys = []string{"version", "file-id"}
+
+// WriteBOM writes a bill of materials (in JSON) listing the given mods, and the files in the index that are not
+// metadata files
+func WriteBOM(w io.Writer, pack Pack, index Index, mods []Mod) error {
+	bom := BOM{
+		Name:       pack.Name,
+		Version:    pack.Version,
+		Versions:   pack.Versions,
+		Components: []BOMComponent{},
+	}
+
+	for _, mod := range mods {
+		for _, destPath := range index.GetModDestPaths(mod) {
+			relPath, err := filepath.Rel(index.GetPackRoot(), destPath)
+			if err != nil {
+				return err
+			}
+			relPath = filepath.ToSlash(relPath)
+			component := BOMComponent{
+				Name:       mod.Name,
+				Type:       bomComponentType(relPath),
+				Path:       relPath,
+				Source:     "url",
+				URL:        mod.Download.URL,
+				HashFormat: mod.Download.HashFormat,
+				Hash:       mod.Download.Hash,
+				Side:       mod.Side,
+			}
+
+			updaterNames := make([]string, 0, len(mod.Update))
+			for k := range mod.Update {
+				updaterNames = append(updaterNames, k)
+			}
+			sort.Strings(updaterNames)
+			if len(updaterNames) > 0 {
+				component.Source = updaterNames[0]
+				component.SourceData = mod.Update[updaterNames[0]]
+				for _, key := range bomVersionKeys {
+					if v, ok := component.SourceData[key]; ok {
+						component.Version = fmt.Sprint(v)
+						break
+					}
+				}
+			}
+			bom.Components = append(bom.Components, component)
+		}
+	}
+
+	for _, f := range index.Files {
+		if f.MetaFile {
+			continue
+		}
+		hashFormat := f.HashFormat
+		if hashFormat == "" {
+			hashFormat = index.HashFormat
+		}
+		relPath, err := filepath.Rel(index.GetPackRoot(), index.GetFileDestPath(f))
+		if err != nil {
+			return err
+		}
+		component := BOMComponent{
+			Name:       filepath.Base(relPath),
+			Type:       "override",
+			Path:       filepath.ToSlash(relPath),
+			Source:     "pack",
+			HashFormat: hashFormat,
+			Hash:       f.Hash,
+		}
+		if info, err := os.Stat(index.GetFilePath(f)); err == nil {
+			component.Size = info.Size()
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(bom)
+}
+
+func bomComponentType(relPath string) string {
+	switch strings.SplitN(relPath, "/", 2)[0] {
+	case "mods":
+		return "mod"
+	case "resourcepacks":
+		return "resourcepack"
+	case "shaderpacks":
+		return "shaderpack"
+	}
+	return "file"
+}
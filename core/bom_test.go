@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteBOM(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "config"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config", "test.cfg"), []byte("enabled=true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index := Index{
+		HashFormat: "sha256",
+		Files: []IndexFile{
+			{File: "mods/test-mod.toml", Hash: "abc", MetaFile: true},
+			{File: "resourcepacks/pack.toml", Hash: "def", MetaFile: true},
+			{File: "config/test.cfg", Hash: "ghi"},
+			{File: "extra/readme.txt", Alias: "readme.txt", HashFormat: "sha1", Hash: "jkl"},
+		},
+		indexFile: filepath.Join(dir, "index.toml"),
+	}
+	pack := Pack{Name: "Test Pack", Version: "1.0.0", Versions: map[string]string{"minecraft": "1.18.2"}}
+	mods := []Mod{
+		{
+			Name:     "Test Mod",
+			FileName: "test-mod.jar",
+			Side:     ClientSide,
+			Download: ModDownload{URL: "https://example.com/test-mod.jar", HashFormat: "sha1", Hash: "123"},
+			Update: map[string]map[string]interface{}{
+				"modrinth":   {"mod-id": "abc", "version": "xyz"},
+				"curseforge": {"project-id": 1, "file-id": 2},
+			},
+			metaFile: filepath.Join(dir, "mods", "test-mod.toml"),
+		},
+		{
+			Name:     "Test Pack",
+			FileName: "pack.zip",
+			Download: ModDownload{URL: "https://example.com/pack.zip", HashFormat: "sha256", Hash: "456"},
+			metaFile: filepath.Join(dir, "resourcepacks", "pack.toml"),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBOM(&buf, pack, index, mods); err != nil {
+		t.Fatal(err)
+	}
+	var got BOM
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	want := BOM{
+		Name:     "Test Pack",
+		Version:  "1.0.0",
+		Versions: map[string]string{"minecraft": "1.18.2"},
+		Components: []BOMComponent{
+			{
+				Name:       "Test Mod",
+				Type:       "mod",
+				Path:       "mods/test-mod.jar",
+				Source:     "curseforge",
+				SourceData: map[string]interface{}{"project-id": float64(1), "file-id": float64(2)},
+				Version:    "2",
+				URL:        "https://example.com/test-mod.jar",
+				HashFormat: "sha1",
+				Hash:       "123",
+				Side:       ClientSide,
+			},
+			{
+				Name:       "Test Pack",
+				Type:       "resourcepack",
+				Path:       "resourcepacks/pack.zip",
+				Source:     "url",
+				URL:        "https://example.com/pack.zip",
+				HashFormat: "sha256",
+				Hash:       "456",
+			},
+			{
+				Name:       "test.cfg",
+				Type:       "override",
+				Path:       "config/test.cfg",
+				Source:     "pack",
+				HashFormat: "sha256",
+				Hash:       "ghi",
+				Size:       int64(len("enabled=true\n")),
+			},
+			{
+				Name:       "readme.txt",
+				Type:       "override",
+				Path:       "readme.txt",
+				Source:     "pack",
+				HashFormat: "sha1",
+				Hash:       "jkl",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteBOM() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -49,9 +49,17 @@ func LoadIndex(indexFile string) (Index, error) {
 	return index, nil
 }
 
-// RemoveFile removes a file from the index.
+// RemoveFile removes a file from the index. The path can be absolute, or relative to the working directory.
 func (in *Index) RemoveFile(path string) error {
-	relPath, err := filepath.Rel(filepath.Dir(in.indexFile), path)
+	indexDir := filepath.Dir(in.indexFile)
+	if filepath.IsAbs(path) {
+		var err error
+		indexDir, err = filepath.Abs(indexDir)
+		if err != nil {
+			return err
+		}
+	}
+	relPath, err := filepath.Rel(indexDir, path)
 	if err != nil {
 		return err
 	}
@@ -71,8 +79,10 @@ func (in *Index) RemoveFile(path string) error {
 // resortIndex sorts Files by file name
 func (in *Index) resortIndex() {
 	sort.SliceStable(in.Files, func(i, j int) bool {
-		// TODO: Compare by alias if names are equal?
 		// TODO: Remove duplicated entries? (compound key on file/alias?)
+		if in.Files[i].File == in.Files[j].File {
+			return in.Files[i].Alias < in.Files[j].Alias
+		}
 		return in.Files[i].File < in.Files[j].File
 	})
 }
@@ -183,6 +193,9 @@ var ignoreDefaults = []string{
 	// Exclude exported Modrinth packs
 	"*.mrpack",
 
+	// Exclude progress saved by an interrupted detect run
+	".packwiz-detect.json",
+
 	// Exclude packwiz binaries, if the user puts them in their pack folder
 	"packwiz.exe",
 	"packwiz", // Note: also excludes packwiz/ as a directory - you can negate this pattern if you want a directory called packwiz
@@ -248,7 +261,7 @@ func (in *Index) Refresh() error {
 		return err
 	}
 
-	progressContainer := mpb.New()
+	progressContainer := mpb.New(mpb.WithOutput(Output))
 	progress := progressContainer.AddBar(int64(len(fileList)),
 		mpb.PrependDecorators(
 			// simple name decorator
@@ -353,9 +366,12 @@ func (in Index) FindMod(modName string) (string, bool) {
 // GetAllMods finds paths to every metadata file (Mod) in the index
 func (in Index) GetAllMods() []string {
 	var list []string
+	seen := make(map[string]bool)
 	baseDir := filepath.Dir(in.indexFile)
 	for _, v := range in.Files {
-		if v.MetaFile {
+		// Metadata files with aliases have multiple entries, but only need to be loaded once
+		if v.MetaFile && !seen[v.File] {
+			seen[v.File] = true
 			list = append(list, filepath.Join(baseDir, filepath.FromSlash(v.File)))
 		}
 	}
@@ -367,6 +383,37 @@ func (in Index) GetFilePath(f IndexFile) string {
 	return filepath.Join(filepath.Dir(in.indexFile), filepath.FromSlash(f.File))
 }
 
+// GetFileDestPath gets the path that an index file should be installed to; its alias if it has one, otherwise the path
+// it is stored at
+func (in Index) GetFileDestPath(f IndexFile) string {
+	if f.Alias != "" {
+		return filepath.Join(filepath.Dir(in.indexFile), filepath.FromSlash(f.Alias))
+	}
+	return in.GetFilePath(f)
+}
+
+// GetModDestPaths gets every path that the file for a mod should be installed to. A mod has one path for each of the
+// index entries for its metadata file; the directory of an entry's alias is used in place of the directory of the
+// metadata file, if it has one.
+func (in Index) GetModDestPaths(m Mod) []string {
+	var list []string
+	baseDir := filepath.Dir(in.indexFile)
+	for _, v := range in.Files {
+		if !v.MetaFile || filepath.Join(baseDir, filepath.FromSlash(v.File)) != filepath.Clean(m.GetFilePath()) {
+			continue
+		}
+		if v.Alias != "" {
+			list = append(list, filepath.Join(baseDir, filepath.Dir(filepath.FromSlash(v.Alias)), m.FileName))
+		} else {
+			list = append(list, m.GetDestFilePath())
+		}
+	}
+	if len(list) == 0 {
+		list = append(list, m.GetDestFilePath())
+	}
+	return list
+}
+
 // SaveFile attempts to read the file from disk
 func (in Index) SaveFile(f IndexFile, dest io.Writer) error {
 	hashFormat := f.HashFormat
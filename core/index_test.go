@@ -0,0 +1,63 @@
+package core
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetFileDestPath(t *testing.T) {
+	dir := t.TempDir()
+	index := Index{indexFile: filepath.Join(dir, "index.toml")}
+
+	tests := []struct {
+		name string
+		file IndexFile
+		want string
+	}{
+		{name: "no alias", file: IndexFile{File: "config/test.cfg"}, want: filepath.Join(dir, "config", "test.cfg")},
+		{name: "alias", file: IndexFile{File: "extra/readme.txt", Alias: "readme.txt"}, want: filepath.Join(dir, "readme.txt")},
+		{name: "alias in another folder", file: IndexFile{File: "multimc/options.txt", Alias: "minecraft/options.txt"},
+			want: filepath.Join(dir, "minecraft", "options.txt")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := index.GetFileDestPath(tt.file); got != tt.want {
+				t.Errorf("GetFileDestPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetModDestPaths(t *testing.T) {
+	dir := t.TempDir()
+	index := Index{
+		Files: []IndexFile{
+			{File: "mods/aliased.pw.toml", MetaFile: true},
+			{File: "mods/aliased.pw.toml", Alias: "minecraft/mods/aliased.pw.toml", MetaFile: true},
+			{File: "mods/plain.pw.toml", MetaFile: true},
+			{File: "mods/plain.pw.toml", Alias: "not-a-metafile.txt"},
+		},
+		indexFile: filepath.Join(dir, "index.toml"),
+	}
+
+	tests := []struct {
+		name     string
+		metaFile string
+		fileName string
+		want     []string
+	}{
+		{name: "aliased", metaFile: "mods/aliased.pw.toml", fileName: "aliased.jar",
+			want: []string{filepath.Join(dir, "mods", "aliased.jar"), filepath.Join(dir, "minecraft", "mods", "aliased.jar")}},
+		{name: "no alias", metaFile: "mods/plain.pw.toml", fileName: "plain.jar", want: []string{filepath.Join(dir, "mods", "plain.jar")}},
+		{name: "not in index", metaFile: "mods/missing.pw.toml", fileName: "missing.jar", want: []string{filepath.Join(dir, "mods", "missing.jar")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod := Mod{FileName: tt.fileName, metaFile: filepath.Join(dir, filepath.FromSlash(tt.metaFile))}
+			if got := index.GetModDestPaths(mod); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetModDestPaths() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// DefaultInstallerURL is the URL of the latest release of the packwiz-installer bootstrap jar
+const DefaultInstallerURL = "https://github.com/packwiz/packwiz-installer-bootstrap/releases/latest/download/packwiz-installer-bootstrap.jar"
+
+// InstallerJarName is the name of the packwiz-installer bootstrap jar embedded in exported packs
+const InstallerJarName = "packwiz-installer-bootstrap.jar"
+
+// InstallerConfigName is the name of the config file embedded in exported packs, which the update scripts read the pack
+// URL and side from
+const InstallerConfigName = "packwiz-update.cfg"
+
+// The update scripts don't contain the pack URL or side themselves, so that they are never interpreted as shell or
+// batch syntax; they are read from the config file as plain values.
+const installerShScript = `#!/usr/bin/env sh
+# Updates the pack using packwiz-installer, with the settings in ` + InstallerConfigName + `; run this before starting
+# the game (e.g. as a pre-launch command)
+cd "$(dirname "$0")" || exit 1
+pack_url=
+side=
+while IFS='=' read -r key value; do
+	case "$key" in
+	pack-url) pack_url="$value" ;;
+	side) side="$value" ;;
+	esac
+done < ` + InstallerConfigName + `
+exec java -jar ` + InstallerJarName + ` -g -s "$side" "$pack_url"
+`
+
+const installerBatScript = "@echo off\r\n" +
+	"rem Updates the pack using packwiz-installer, with the settings in " + InstallerConfigName + "; run this before starting\r\n" +
+	"rem the game (e.g. as a pre-launch command)\r\n" +
+	"cd /d \"%~dp0\"\r\n" +
+	"for /f \"usebackq tokens=1,* delims==\" %%a in (\"" + InstallerConfigName + "\") do (\r\n" +
+	"\tif \"%%a\"==\"pack-url\" set \"PACK_URL=%%b\"\r\n" +
+	"\tif \"%%a\"==\"side\" set \"SIDE=%%b\"\r\n" +
+	")\r\n" +
+	"java -jar " + InstallerJarName + " -g -s \"%SIDE%\" \"%PACK_URL%\"\r\n"
+
+// ValidatePackURL checks that packURL is an absolute http or https URL, that packwiz-installer can update a pack from
+func ValidatePackURL(packURL string) error {
+	u, err := url.Parse(packURL)
+	if err != nil {
+		return fmt.Errorf("invalid pack URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid pack URL %s: must be an http or https URL", packURL)
+	}
+	return nil
+}
+
+// WriteEmbeddedInstaller downloads the packwiz-installer bootstrap jar from installerURL and adds it to the given
+// folder of an exported pack, along with a config file for updating the pack from packURL (the URL of pack.toml) for
+// the given side, and scripts that run it with this config. When added to the launcher's pre-launch command, this keeps
+// the pack up to date.
+func WriteEmbeddedInstaller(exp *ExportZip, dir string, installerURL string, packURL string, side string) error {
+	err := ValidatePackURL(packURL)
+	if err != nil {
+		return err
+	}
+	// Normalise the URL, so that it doesn't contain characters (e.g. quotes) that the scripts can't pass through
+	u, _ := url.Parse(packURL)
+	packURL = u.String()
+	if side != ClientSide && side != ServerSide && side != UniversalSide {
+		return errors.New("invalid side " + side)
+	}
+
+	resp, err := http.Get(installerURL)
+	if err != nil {
+		return fmt.Errorf("failed to download packwiz-installer: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download packwiz-installer: invalid response status %v", resp.Status)
+	}
+
+	jarFile, err := exp.Create(dir + "/" + InstallerJarName)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(jarFile, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download packwiz-installer: %w", err)
+	}
+
+	configFile, err := exp.Create(dir + "/" + InstallerConfigName)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(configFile, "pack-url="+packURL+"\nside="+side+"\n")
+	if err != nil {
+		return err
+	}
+	shFile, err := exp.CreateExecutable(dir + "/packwiz-update.sh")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(shFile, installerShScript)
+	if err != nil {
+		return err
+	}
+	batFile, err := exp.Create(dir + "/packwiz-update.bat")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(batFile, installerBatScript)
+	return err
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidatePackURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://example.com/pack/pack.toml", false},
+		{"http://example.com/my%20pack/pack.toml", false},
+		{"ftp://example.com/pack.toml", true},
+		{"pack.toml", true},
+		{"https:///pack.toml", true},
+		{"https://example.com/pack\n.toml", true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePackURL(tt.url); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePackURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteEmbeddedInstaller(t *testing.T) {
+	jar := []byte("bootstrap jar")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write(jar)
+	}))
+	defer server.Close()
+
+	// Characters that are special in sh or batch scripts must be passed through unchanged
+	packURL := "https://example.com/my%20pack/$(touch%20pwned)/pack.toml?a=b&c=d"
+
+	var buf bytes.Buffer
+	exp := NewExportZip(&buf)
+	err := WriteEmbeddedInstaller(exp, "overrides", server.URL+"/bootstrap.jar", packURL, ClientSide)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	modes := make(map[string]os.FileMode)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], err = ioutil.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		modes[f.Name] = f.Mode()
+	}
+
+	if !bytes.Equal(files["overrides/"+InstallerJarName], jar) {
+		t.Errorf("installer jar not embedded")
+	}
+	wantConfig := "pack-url=" + packURL + "\nside=client\n"
+	if config := string(files["overrides/"+InstallerConfigName]); config != wantConfig {
+		t.Errorf("config = %q, want %q", config, wantConfig)
+	}
+	for _, name := range []string{"overrides/packwiz-update.sh", "overrides/packwiz-update.bat"} {
+		script, ok := files[name]
+		if !ok {
+			t.Fatalf("%s not embedded", name)
+		}
+		if strings.Contains(string(script), "example.com") {
+			t.Errorf("%s contains the pack URL; it should be read from the config", name)
+		}
+	}
+	if modes["overrides/packwiz-update.sh"]&0111 == 0 {
+		t.Errorf("packwiz-update.sh is not executable")
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	// Run the shell script with a fake java, to check the arguments it is given
+	dir := t.TempDir()
+	binDir := filepath.Join(dir, "bin")
+	packDir := filepath.Join(dir, "pack")
+	for _, d := range []string{binDir, packDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = ioutil.WriteFile(filepath.Join(binDir, "java"), []byte("#!/bin/sh\nfor arg in \"$@\"; do echo \"$arg\"; done > args.txt\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"packwiz-update.sh", InstallerConfigName} {
+		if err := ioutil.WriteFile(filepath.Join(packDir, name), files["overrides/"+name], 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("sh", filepath.Join(packDir, "packwiz-update.sh"))
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("packwiz-update.sh failed: %v: %s", err, out)
+	}
+	args, err := ioutil.ReadFile(filepath.Join(packDir, "args.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantArgs := "-jar\n" + InstallerJarName + "\n-g\n-s\nclient\n" + packURL + "\n"
+	if string(args) != wantArgs {
+		t.Errorf("java arguments = %q, want %q", args, wantArgs)
+	}
+	if _, err := os.Stat(filepath.Join(packDir, "pwned")); err == nil {
+		t.Errorf("pack URL was executed by the shell")
+	}
+}
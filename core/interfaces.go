@@ -1,5 +1,7 @@
 package core
 
+import "context"
+
 // Updaters stores all the updaters that packwiz can use. Add your own update systems to this map, keyed by the configuration name.
 var Updaters = make(map[string]Updater)
 
@@ -10,10 +12,10 @@ type Updater interface {
 	ParseUpdate(map[string]interface{}) (interface{}, error)
 	// CheckUpdate checks whether there is an update for each of the mods in the given slice, for the given MC version,
 	// called for all of the mods that this updater handles
-	CheckUpdate([]Mod, string, Pack) ([]UpdateCheck, error)
+	CheckUpdate(context.Context, []Mod, string, Pack) ([]UpdateCheck, error)
 	// DoUpdate carries out the update previously queried in CheckUpdate, on each Mod's metadata,
 	// given pointers to Mods and the value of CachedState for each mod
-	DoUpdate([]*Mod, []interface{}) error
+	DoUpdate(context.Context, []*Mod, []interface{}) error
 }
 
 // UpdateCheck represents the data returned from CheckUpdate for each mod
@@ -25,8 +27,90 @@ type UpdateCheck struct {
 	UpdateString string
 	// CachedState can be used to preserve per-mod state between CheckUpdate and DoUpdate (e.g. file metadata)
 	CachedState interface{}
+	// Warning is shown to the user if it is set, whether or not an update is available (e.g. if the mod's project has
+	// been removed, so it can no longer be updated)
+	Warning string
 	// Error stores an error for this specific mod
 	// Errors can also be returned from CheckUpdate directly, if the whole operation failed completely (so only 1 error is printed)
 	// If an error is returned for a mod, or from CheckUpdate, DoUpdate is not called on that mod / at all
 	Error error
 }
+
+// MetadataMigrator can optionally be implemented by an Updater, to upgrade outdated metadata when the index is refreshed
+type MetadataMigrator interface {
+	// MigrateMetadata upgrades the metadata of each of the given mods in place (called for all of the mods that this
+	// updater handles), returning whether each mod was changed
+	MigrateMetadata(context.Context, []*Mod) ([]bool, error)
+}
+
+// DependencyLister can optionally be implemented by an Updater, to find the dependencies of mods (e.g. to remove
+// dependencies that are no longer needed)
+type DependencyLister interface {
+	// ListDependencies returns the ID of the project of each of the given mods, and the IDs of the projects that each
+	// mod requires (called for all of the mods that this updater handles). IDs are only compared with other IDs from
+	// the same updater.
+	ListDependencies(context.Context, []Mod) ([]string, [][]string, error)
+}
+
+// StatusReporter can optionally be implemented by an Updater, to report problems with mods recorded in their metadata
+// (e.g. when the index is refreshed) without network access
+type StatusReporter interface {
+	// GetStatus returns a warning for the given mod, or an empty string if there is nothing to report
+	GetStatus(Mod) string
+}
+
+// FileSelector can optionally be implemented by an Updater, to select the best file of mods for a pack regardless of
+// the file that is currently installed (e.g. to export a variant of the pack for a different loader)
+type FileSelector interface {
+	// SelectFiles finds the best compatible file of each of the given mods for the given MC version (called for all of
+	// the mods that this updater handles). UpdateAvailable is false for mods without a compatible file; for the other
+	// mods, CachedState is passed to DoUpdate to switch to the selected file.
+	SelectFiles(context.Context, []Mod, string, Pack) ([]UpdateCheck, error)
+}
+
+// FileIdentifiers stores all the systems that packwiz can use to find which project provides a file, keyed by name
+var FileIdentifiers = make(map[string]FileIdentifier)
+
+// FileIdentifier is used to look up which project provides a file
+type FileIdentifier interface {
+	// IdentifyFile takes the contents of a file, returning information on the project that provides it and whether it
+	// was found
+	IdentifyFile(context.Context, []byte) (FileIdentification, bool, error)
+}
+
+// FileIdentification represents the data returned from IdentifyFile
+type FileIdentification struct {
+	// ProjectName is the name of the project that provides the file
+	ProjectName string
+	// URL is a link to the project's page
+	URL string
+	// Version is the name of the version of the project that the file is from
+	Version string
+}
+
+// LicenseResolver can optionally be implemented by an Updater, to find the licenses of mods for license summaries
+type LicenseResolver interface {
+	// ResolveLicenses returns the SPDX license identifier of each of the given mods (called for all of the mods that
+	// this updater handles), or an empty string for mods where it is unknown
+	ResolveLicenses(context.Context, []Mod) ([]string, error)
+}
+
+// ModSources stores the systems that mods can be installed from, keyed by name, so that a mod can be installed from
+// another source when one is unavailable
+var ModSources = make(map[string]ModSource)
+
+// ModSource is used to find mods to install
+type ModSource interface {
+	// FindMod looks up a mod by slug or name, returning it and whether it was found
+	FindMod(ctx context.Context, query string, pack Pack) (FoundMod, bool, error)
+}
+
+// FoundMod represents a mod returned from FindMod
+type FoundMod struct {
+	// Name is the name of the mod
+	Name string
+	// URL is a link to the mod's page
+	URL string
+	// Install adds the mod to the pack
+	Install func() error
+}
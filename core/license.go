@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// LicenseSummaryFile is the name of the license summary written to exported packs
+const LicenseSummaryFile = "licenses.spdx"
+
+var spdxIDInvalidChars = regexp.MustCompile("[^A-Za-z0-9.-]")
+
+// WriteLicenseSummary writes an SPDX (tag-value format) document listing the license of each mod, as found by the
+// updaters for each mod that implement LicenseResolver
+func WriteLicenseSummary(ctx context.Context, w io.Writer, packName string, mods []Mod) error {
+	licenses := make([]string, len(mods))
+	updaterMods := make(map[string][]int)
+	for i, mod := range mods {
+		for k := range mod.Update {
+			if _, ok := Updaters[k].(LicenseResolver); ok {
+				updaterMods[k] = append(updaterMods[k], i)
+				break
+			}
+		}
+	}
+	for k, indexes := range updaterMods {
+		modsList := make([]Mod, len(indexes))
+		for i, v := range indexes {
+			modsList[i] = mods[v]
+		}
+		resolved, err := Updaters[k].(LicenseResolver).ResolveLicenses(ctx, modsList)
+		if err != nil {
+			return fmt.Errorf("failed to get licenses for %s mods: %w", k, err)
+		}
+		for i, v := range indexes {
+			licenses[v] = resolved[i]
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "SPDXVersion: SPDX-2.2\nDataLicense: CC0-1.0\nSPDXID: SPDXRef-DOCUMENT\nDocumentName: %s\n", packName)
+	if err != nil {
+		return err
+	}
+	for i, mod := range mods {
+		license := licenses[i]
+		if license == "" {
+			license = "NOASSERTION"
+		}
+		downloadLocation := mod.Download.URL
+		if downloadLocation == "" {
+			downloadLocation = "NOASSERTION"
+		}
+		_, err = fmt.Fprintf(w, "\nPackageName: %s\nSPDXID: SPDXRef-%d-%s\nPackageFileName: %s\nPackageDownloadLocation: %s\nPackageLicenseConcluded: NOASSERTION\nPackageLicenseDeclared: %s\nPackageCopyrightText: NOASSERTION\n",
+			mod.Name, i, spdxIDInvalidChars.ReplaceAllString(mod.Name, "-"), mod.FileName, downloadLocation, license)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
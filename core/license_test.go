@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// licenseTestUpdater resolves licenses from a fixed list, keyed by mod name
+type licenseTestUpdater struct {
+	licenses map[string]string
+}
+
+func (u licenseTestUpdater) ParseUpdate(updateUnparsed map[string]interface{}) (interface{}, error) {
+	return updateUnparsed, nil
+}
+
+func (u licenseTestUpdater) CheckUpdate(ctx context.Context, mods []Mod, mcVersion string, pack Pack) ([]UpdateCheck, error) {
+	return make([]UpdateCheck, len(mods)), nil
+}
+
+func (u licenseTestUpdater) DoUpdate(ctx context.Context, mods []*Mod, cachedState []interface{}) error {
+	return nil
+}
+
+func (u licenseTestUpdater) ResolveLicenses(ctx context.Context, mods []Mod) ([]string, error) {
+	licenses := make([]string, len(mods))
+	for i, mod := range mods {
+		licenses[i] = u.licenses[mod.Name]
+	}
+	return licenses, nil
+}
+
+func TestWriteLicenseSummary(t *testing.T) {
+	Updaters["licensetest"] = licenseTestUpdater{licenses: map[string]string{
+		"Sodium": "LGPL-3.0-only",
+		"JEI":    "MIT",
+	}}
+	defer delete(Updaters, "licensetest")
+
+	update := map[string]map[string]interface{}{"licensetest": {}}
+	mods := []Mod{
+		{Name: "Sodium", FileName: "sodium.jar", Download: ModDownload{URL: "https://example.com/sodium.jar"}, Update: update},
+		{Name: "JEI", FileName: "jei.jar", Update: update},
+		{Name: "Unknown", FileName: "unknown.jar", Update: update},
+		{Name: "Manual mod", FileName: "manual.jar"},
+	}
+
+	var buf bytes.Buffer
+	err := WriteLicenseSummary(context.Background(), &buf, "Test pack", mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary := buf.String()
+	if !strings.HasPrefix(summary, "SPDXVersion: SPDX-2.2\n") || !strings.Contains(summary, "DocumentName: Test pack\n") {
+		t.Errorf("summary is missing the document header:\n%s", summary)
+	}
+	packages := strings.Split(summary, "\nPackageName: ")[1:]
+	if len(packages) != len(mods) {
+		t.Fatalf("summary lists %d packages, want %d", len(packages), len(mods))
+	}
+	tests := []struct {
+		name             string
+		license          string
+		downloadLocation string
+	}{
+		{"Sodium", "LGPL-3.0-only", "https://example.com/sodium.jar"},
+		{"JEI", "MIT", "NOASSERTION"},
+		{"Unknown", "NOASSERTION", "NOASSERTION"},
+		{"Manual mod", "NOASSERTION", "NOASSERTION"},
+	}
+	for i, tt := range tests {
+		if !strings.HasPrefix(packages[i], tt.name+"\n") {
+			t.Errorf("package %d is %q, want %s", i, strings.SplitN(packages[i], "\n", 2)[0], tt.name)
+		}
+		if !strings.Contains(packages[i], "\nPackageLicenseDeclared: "+tt.license+"\n") {
+			t.Errorf("license of %s is not %s:\n%s", tt.name, tt.license, packages[i])
+		}
+		if !strings.Contains(packages[i], "\nPackageDownloadLocation: "+tt.downloadLocation+"\n") {
+			t.Errorf("download location of %s is not %s:\n%s", tt.name, tt.downloadLocation, packages[i])
+		}
+	}
+	if !strings.Contains(summary, "SPDXID: SPDXRef-3-Manual-mod\n") {
+		t.Errorf("SPDX IDs are not sanitised:\n%s", summary)
+	}
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// loaderComponents are the components in the versions section of pack.toml that are mod loaders
+var loaderComponents = []string{"fabric", "forge", "liteloader", "neoforge", "quilt"}
+
+// WithTargetLoader returns a copy of the pack using only the given loader, for exporting a variant of the pack for that
+// loader. If version is empty, the latest version of the loader for the pack's Minecraft version is used.
+func (pack Pack) WithTargetLoader(loader string, version string) (Pack, error) {
+	isLoader := false
+	for _, v := range loaderComponents {
+		if v == loader {
+			isLoader = true
+			break
+		}
+	}
+	if !isLoader {
+		return pack, fmt.Errorf("unknown loader %s", loader)
+	}
+
+	mcVersion, err := pack.GetMCVersion()
+	if err != nil {
+		return pack, err
+	}
+	if version == "" {
+		if existing, ok := pack.Versions[loader]; ok {
+			version = existing
+		} else {
+			loaderComponent, ok := ModLoaders[loader]
+			if !ok {
+				return pack, fmt.Errorf("a version must be given for %s", loader)
+			}
+			_, version, err = loaderComponent.VersionListGetter(mcVersion)
+			if err != nil {
+				return pack, fmt.Errorf("failed to get the latest %s version: %w", loaderComponent.FriendlyName, err)
+			}
+		}
+	}
+
+	versions := make(map[string]string)
+	for k, v := range pack.Versions {
+		versions[k] = v
+	}
+	for _, v := range loaderComponents {
+		delete(versions, v)
+	}
+	versions[loader] = version
+	pack.Versions = versions
+	return pack, nil
+}
+
+// SelectModsForPack returns copies of the given mods, with the best files for the given pack (e.g. a variant of the
+// pack for a different loader) selected by their updaters, regardless of the files that are currently installed. Mods
+// without an updater that can select files are left unchanged and are returned in the list of skipped mod names; if
+// any mod has no file compatible with the pack, an error listing them is returned.
+func SelectModsForPack(ctx context.Context, mods []Mod, pack Pack) ([]Mod, []string, error) {
+	mcVersion, err := pack.GetMCVersion()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selected := make([]Mod, len(mods))
+	copy(selected, mods)
+	for i := range selected {
+		// Updaters change the update data in place, so it is copied to leave the given mods unchanged
+		update := make(map[string]map[string]interface{}, len(selected[i].Update))
+		for k, v := range selected[i].Update {
+			data := make(map[string]interface{}, len(v))
+			for dk, dv := range v {
+				data[dk] = dv
+			}
+			update[k] = data
+		}
+		selected[i].Update = update
+	}
+	var skipped []string
+
+	updaterMods := make(map[string][]int)
+	for i, mod := range selected {
+		updaterFound := false
+		for k := range mod.Update {
+			if updater, ok := Updaters[k]; ok {
+				if _, ok := updater.(FileSelector); ok {
+					updaterMods[k] = append(updaterMods[k], i)
+					updaterFound = true
+					break
+				}
+			}
+		}
+		if !updaterFound {
+			skipped = append(skipped, mod.Name)
+		}
+	}
+
+	updaterNames := make([]string, 0, len(updaterMods))
+	for k := range updaterMods {
+		updaterNames = append(updaterNames, k)
+	}
+	sort.Strings(updaterNames)
+
+	var incompatible []string
+	for _, k := range updaterNames {
+		indexes := updaterMods[k]
+		modsList := make([]Mod, len(indexes))
+		for i, v := range indexes {
+			modsList[i] = selected[v]
+		}
+		checks, err := Updaters[k].(FileSelector).SelectFiles(ctx, modsList, mcVersion, pack)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to select files for %s: %w", k, err)
+		}
+
+		var updatePointers []*Mod
+		var cachedState []interface{}
+		for i, check := range checks {
+			if check.Error != nil {
+				return nil, nil, fmt.Errorf("failed to select a file for %s: %w", modsList[i].Name, check.Error)
+			}
+			if !check.UpdateAvailable {
+				incompatible = append(incompatible, modsList[i].Name)
+				continue
+			}
+			updatePointers = append(updatePointers, &selected[indexes[i]])
+			cachedState = append(cachedState, check.CachedState)
+		}
+		if len(updatePointers) > 0 {
+			err = Updaters[k].DoUpdate(ctx, updatePointers, cachedState)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to select files for %s: %w", k, err)
+			}
+		}
+	}
+	if len(incompatible) > 0 {
+		sort.Strings(incompatible)
+		return nil, nil, fmt.Errorf("no compatible file was found for: %s", strings.Join(incompatible, ", "))
+	}
+	return selected, skipped, nil
+}
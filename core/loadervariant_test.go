@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// variantTestUpdater selects files from a fixed list of files for each loader, keyed by mod name
+type variantTestUpdater struct {
+	files map[string]map[string]string
+}
+
+func (u variantTestUpdater) ParseUpdate(updateUnparsed map[string]interface{}) (interface{}, error) {
+	return updateUnparsed, nil
+}
+
+func (u variantTestUpdater) CheckUpdate(ctx context.Context, mods []Mod, mcVersion string, pack Pack) ([]UpdateCheck, error) {
+	// Updates are never available, so files must be selected regardless of the installed file
+	return make([]UpdateCheck, len(mods)), nil
+}
+
+func (u variantTestUpdater) SelectFiles(ctx context.Context, mods []Mod, mcVersion string, pack Pack) ([]UpdateCheck, error) {
+	results := make([]UpdateCheck, len(mods))
+	for i, mod := range mods {
+		for loader, fileName := range u.files[mod.Name] {
+			if _, ok := pack.Versions[loader]; ok {
+				results[i] = UpdateCheck{UpdateAvailable: true, CachedState: fileName}
+			}
+		}
+	}
+	return results, nil
+}
+
+func (u variantTestUpdater) DoUpdate(ctx context.Context, mods []*Mod, cachedState []interface{}) error {
+	for i, mod := range mods {
+		mod.FileName = cachedState[i].(string)
+		mod.Update["varianttest"]["file"] = mod.FileName
+	}
+	return nil
+}
+
+func TestSelectModsForPack(t *testing.T) {
+	Updaters["varianttest"] = variantTestUpdater{files: map[string]map[string]string{
+		"Both":        {"fabric": "both-fabric.jar", "forge": "both-forge.jar"},
+		"Fabric only": {"fabric": "fabric-only.jar"},
+	}}
+	defer delete(Updaters, "varianttest")
+
+	newMod := func(name string, fileName string) Mod {
+		return Mod{Name: name, FileName: fileName, Update: map[string]map[string]interface{}{
+			"varianttest": {"file": fileName},
+		}}
+	}
+	pack := Pack{Versions: map[string]string{"minecraft": "1.20.1", "forge": "47.1.0"}}
+
+	tests := []struct {
+		name         string
+		loader       string
+		mods         []Mod
+		wantFiles    []string
+		wantSkipped  []string
+		wantErrNames []string
+	}{
+		{
+			name:      "fabric variant",
+			loader:    "fabric",
+			mods:      []Mod{newMod("Both", "both-forge.jar"), newMod("Fabric only", "fabric-only.jar")},
+			wantFiles: []string{"both-fabric.jar", "fabric-only.jar"},
+		},
+		{
+			name:      "forge variant",
+			loader:    "forge",
+			mods:      []Mod{newMod("Both", "both-fabric.jar")},
+			wantFiles: []string{"both-forge.jar"},
+		},
+		{
+			name:         "no compatible file",
+			loader:       "forge",
+			mods:         []Mod{newMod("Both", "both-fabric.jar"), newMod("Fabric only", "fabric-only.jar")},
+			wantErrNames: []string{"Fabric only"},
+		},
+		{
+			name:        "no updater",
+			loader:      "fabric",
+			mods:        []Mod{{Name: "Manual", FileName: "manual.jar"}},
+			wantFiles:   []string{"manual.jar"},
+			wantSkipped: []string{"Manual"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, err := pack.WithTargetLoader(tt.loader, "1.0.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range loaderComponents {
+				if _, ok := variant.Versions[v]; ok != (v == tt.loader) {
+					t.Errorf("loader %s in variant versions: %v", v, ok)
+				}
+			}
+
+			selected, skipped, err := SelectModsForPack(context.Background(), tt.mods, variant)
+			if len(tt.wantErrNames) > 0 {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				for _, v := range tt.wantErrNames {
+					if !strings.Contains(err.Error(), v) {
+						t.Errorf("error %q doesn't list %s", err, v)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range selected {
+				if v.FileName != tt.wantFiles[i] {
+					t.Errorf("selected %s for %s, want %s", v.FileName, v.Name, tt.wantFiles[i])
+				}
+				if tt.mods[i].Update != nil && tt.mods[i].Update["varianttest"]["file"] == v.FileName && tt.mods[i].FileName != v.FileName {
+					t.Errorf("update data of the original %s was changed", v.Name)
+				}
+			}
+			if strings.Join(skipped, ",") != strings.Join(tt.wantSkipped, ",") {
+				t.Errorf("skipped %v, want %v", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
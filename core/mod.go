@@ -7,9 +7,12 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/spf13/viper"
 )
 
 // Mod stores metadata about a mod. This is written to a TOML file for each mod.
@@ -31,6 +34,10 @@ type ModDownload struct {
 	URL        string `toml:"url"`
 	HashFormat string `toml:"hash-format"`
 	Hash       string `toml:"hash"`
+	// URLTemplate is used to generate URL when refreshing, replacing placeholders such as {version} with the values
+	// in Variables ({filename} is replaced with the file name of the mod, unless it is set in Variables)
+	URLTemplate string            `toml:"url-template,omitempty"`
+	Variables   map[string]string `toml:"variables,omitempty"`
 }
 
 // ModOption specifies optional metadata for this mod file
@@ -48,6 +55,21 @@ const (
 	UniversalSide = "both"
 )
 
+// GetDefaultSide returns the side that newly added mods should use: the given side if it is set, otherwise the
+// default-side option, or both sides if neither are set
+func GetDefaultSide(side string) (string, error) {
+	if side == "" {
+		side = viper.GetString("default-side")
+	}
+	switch side {
+	case "":
+		return UniversalSide, nil
+	case ServerSide, ClientSide, UniversalSide:
+		return side, nil
+	}
+	return "", fmt.Errorf("invalid side %s (must be client, server or both)", side)
+}
+
 // LoadMod attempts to load a mod file from a path
 func LoadMod(modFile string) (Mod, error) {
 	var mod Mod
@@ -127,6 +149,56 @@ func (m Mod) GetDestFilePath() string {
 	return filepath.Join(filepath.Dir(m.metaFile), filepath.FromSlash(m.FileName))
 }
 
+var urlTemplateVarRegex = regexp.MustCompile(`\{([A-Za-z0-9_-]+)}`)
+
+// RenderURLTemplate sets the download URL from the URL template, if there is one. If the URL changes, the file is
+// downloaded to update the hash. Returns true if the URL has changed.
+func (m *Mod) RenderURLTemplate() (bool, error) {
+	if m.Download.URLTemplate == "" {
+		return false, nil
+	}
+
+	var missing []string
+	u := urlTemplateVarRegex.ReplaceAllStringFunc(m.Download.URLTemplate, func(match string) string {
+		name := match[1 : len(match)-1]
+		if value, ok := m.Download.Variables[name]; ok {
+			return value
+		}
+		if name == "filename" {
+			return m.FileName
+		}
+		missing = append(missing, name)
+		return match
+	})
+	if len(missing) > 0 {
+		return false, fmt.Errorf("unknown variables in URL template: %s", strings.Join(missing, ", "))
+	}
+	if u == m.Download.URL {
+		return false, nil
+	}
+
+	resp, err := http.Get(u)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return false, fmt.Errorf("failed to download %s: invalid status code %d", u, resp.StatusCode)
+	}
+	h, stringer, err := GetHashImpl(m.Download.HashFormat)
+	if err != nil {
+		return false, err
+	}
+	_, err = io.Copy(h, resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	m.Download.URL = u
+	m.Download.Hash = stringer.HashToString(h.Sum(nil))
+	return true, nil
+}
+
 // DownloadFile attempts to resolve and download the file
 func (m Mod) DownloadFile(dest io.Writer) error {
 	resp, err := http.Get(m.Download.URL)
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRenderURLTemplate(t *testing.T) {
+	content := []byte("mod file contents")
+	sum := sha256.Sum256(content)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/1.2.0/test-mod.jar" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		template    string
+		variables   map[string]string
+		url         string
+		wantURL     string
+		wantHash    string
+		wantChanged bool
+		wantErr     bool
+	}{
+		{name: "no template", url: "https://example.com/mod.jar", wantURL: "https://example.com/mod.jar", wantHash: "old"},
+		{
+			name:        "rendered",
+			template:    server.URL + "/{version}/{filename}",
+			variables:   map[string]string{"version": "1.2.0"},
+			url:         server.URL + "/1.1.0/test-mod.jar",
+			wantURL:     server.URL + "/1.2.0/test-mod.jar",
+			wantHash:    hex.EncodeToString(sum[:]),
+			wantChanged: true,
+		},
+		{
+			name:      "unchanged",
+			template:  server.URL + "/{version}/{filename}",
+			variables: map[string]string{"version": "1.2.0"},
+			url:       server.URL + "/1.2.0/test-mod.jar",
+			wantURL:   server.URL + "/1.2.0/test-mod.jar",
+			wantHash:  "old",
+		},
+		{
+			name:     "unknown variable",
+			template: server.URL + "/{version}/{filename}",
+			url:      server.URL + "/1.1.0/test-mod.jar",
+			wantURL:  server.URL + "/1.1.0/test-mod.jar",
+			wantHash: "old",
+			wantErr:  true,
+		},
+		{
+			name:      "download fails",
+			template:  server.URL + "/{version}/missing.jar",
+			variables: map[string]string{"version": "1.2.0"},
+			url:       server.URL + "/1.1.0/test-mod.jar",
+			wantURL:   server.URL + "/1.1.0/test-mod.jar",
+			wantHash:  "old",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Mod{
+				FileName: "test-mod.jar",
+				Download: ModDownload{
+					URL:         tt.url,
+					HashFormat:  "sha256",
+					Hash:        "old",
+					URLTemplate: tt.template,
+					Variables:   tt.variables,
+				},
+			}
+			changed, err := m.RenderURLTemplate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderURLTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("RenderURLTemplate() = %v, want %v", changed, tt.wantChanged)
+			}
+			if m.Download.URL != tt.wantURL || m.Download.Hash != tt.wantHash {
+				t.Errorf("download is %s (%s), want %s (%s)", m.Download.URL, m.Download.Hash, tt.wantURL, tt.wantHash)
+			}
+		})
+	}
+}
@@ -53,7 +53,7 @@ func LoadPack() (Pack, error) {
 
 	// Check pack-format
 	if len(modpack.PackFormat) == 0 {
-		fmt.Println("Modpack manifest has no pack-format field; assuming packwiz:1.0.0")
+		fmt.Fprintln(Output, "Modpack manifest has no pack-format field; assuming packwiz:1.0.0")
 		modpack.PackFormat = "packwiz:1.0.0"
 	}
 	if !strings.HasPrefix(modpack.PackFormat, "packwiz:") {
@@ -67,7 +67,7 @@ func LoadPack() (Pack, error) {
 		return Pack{}, errors.New("the modpack is incompatible with this version of packwiz; please update")
 	}
 	if !PackFormatConstraintSuggestUpgrade.Check(ver) {
-		fmt.Println("Modpack has a newer feature number than is supported by this version of packwiz. Update to the latest version of packwiz for new features and bugfixes!")
+		fmt.Fprintln(os.Stderr, "Modpack has a newer feature number than is supported by this version of packwiz. Update to the latest version of packwiz for new features and bugfixes!")
 	}
 	// TODO: suggest migration if necessary (primarily for 2.0.0)
 
@@ -94,10 +94,37 @@ func (pack Pack) LoadIndex() (Index, error) {
 	return LoadIndex(filepath.Join(filepath.Dir(viper.GetString("pack-file")), fileNative))
 }
 
+// indexHashFormats are the formats that can be used for the hash of the index file; as it verifies every other file
+// in the pack, only strong hashes are allowed
+var indexHashFormats = []string{"sha256", "sha512"}
+
+// SetIndexHashFormat sets the format used for the hash of the index file, which is saved in the pack file so that the
+// same format is used everywhere the pack is refreshed
+func (pack *Pack) SetIndexHashFormat(format string) error {
+	format = strings.ToLower(format)
+	for _, v := range indexHashFormats {
+		if v == format {
+			pack.Index.HashFormat = format
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid index hash format %s (must be one of %s)", format, strings.Join(indexHashFormats, ", "))
+}
+
 // UpdateIndexHash recalculates the hash of the index file of this modpack
 func (pack *Pack) UpdateIndexHash() error {
+	// Hash usage strategy (may change):
+	// Use the format saved in the pack file (SHA256 by default), overwrite existing hash regardless of what it is
+	// This is independent of the format used for the hashes of files in the index
+	hashFormat := pack.Index.HashFormat
+	if hashFormat == "" {
+		hashFormat = "sha256"
+	}
+	if err := pack.SetIndexHashFormat(hashFormat); err != nil {
+		return fmt.Errorf("%w in %s", err, viper.GetString("pack-file"))
+	}
+
 	if viper.GetBool("no-internal-hashes") {
-		pack.Index.HashFormat = "sha256"
 		pack.Index.Hash = ""
 		return nil
 	}
@@ -113,10 +140,7 @@ func (pack *Pack) UpdateIndexHash() error {
 		return err
 	}
 
-	// Hash usage strategy (may change):
-	// Just use SHA256, overwrite existing hash regardless of what it is
-	// May update later to continue using the same hash that was already being used
-	h, stringer, err := GetHashImpl("sha256")
+	h, stringer, err := GetHashImpl(pack.Index.HashFormat)
 	if err != nil {
 		_ = f.Close()
 		return err
@@ -125,10 +149,7 @@ func (pack *Pack) UpdateIndexHash() error {
 		_ = f.Close()
 		return err
 	}
-	hashString := stringer.HashToString(h.Sum(nil))
-
-	pack.Index.HashFormat = "sha256"
-	pack.Index.Hash = hashString
+	pack.Index.Hash = stringer.HashToString(h.Sum(nil))
 	return f.Close()
 }
 
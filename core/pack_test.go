@@ -0,0 +1,71 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateIndexHash(t *testing.T) {
+	// The files in the index use SHA1 hashes, which are cheaper but not used for the index hash
+	indexData := []byte("hash-format = \"sha1\"\n\n[[files]]\nfile = \"config/test.cfg\"\nhash = \"da39a3ee5e6b4b0d3255bfef95601890afd80709\"\n")
+	indexFile := filepath.Join(t.TempDir(), "index.toml")
+	if err := ioutil.WriteFile(indexFile, indexData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sha256Hash := sha256.Sum256(indexData)
+	sha512Hash := sha512.Sum512(indexData)
+
+	tests := []struct {
+		name       string
+		format     string
+		wantFormat string
+		wantHash   string
+		wantErr    bool
+	}{
+		{name: "default", format: "", wantFormat: "sha256", wantHash: hex.EncodeToString(sha256Hash[:])},
+		{name: "sha256", format: "sha256", wantFormat: "sha256", wantHash: hex.EncodeToString(sha256Hash[:])},
+		{name: "sha512", format: "SHA512", wantFormat: "sha512", wantHash: hex.EncodeToString(sha512Hash[:])},
+		{name: "weak hash", format: "sha1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack := Pack{}
+			pack.Index.File = indexFile
+			if tt.format != "" {
+				err := pack.SetIndexHashFormat(tt.format)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("SetIndexHashFormat() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+			}
+			if err := pack.UpdateIndexHash(); err != nil {
+				t.Fatal(err)
+			}
+			if pack.Index.HashFormat != tt.wantFormat || pack.Index.Hash != tt.wantHash {
+				t.Errorf("UpdateIndexHash() = %s %s, want %s %s", pack.Index.HashFormat, pack.Index.Hash, tt.wantFormat, tt.wantHash)
+			}
+		})
+	}
+
+	// A weak format set in the pack file is rejected
+	pack := Pack{}
+	pack.Index.File = indexFile
+	pack.Index.HashFormat = "md5"
+	if err := pack.UpdateIndexHash(); err == nil {
+		t.Error("UpdateIndexHash() accepted an MD5 index hash")
+	}
+
+	index, err := LoadIndex(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.HashFormat != "sha1" {
+		t.Errorf("hash format of the index files changed to %s", index.HashFormat)
+	}
+}
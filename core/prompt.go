@@ -0,0 +1,20 @@
+package core
+
+import (
+	"io"
+	"os"
+)
+
+// Output is where messages showing progress are written. It is standard output by default; with --quiet, it is
+// discarded, so that only errors and warnings (which are written to standard error) are shown.
+var Output io.Writer = os.Stdout
+
+// PromptOutput is where questions asking for user input are written. It is standard output, and isn't discarded with
+// --quiet, so that prompts are still visible.
+var PromptOutput io.Writer = os.Stdout
+
+// IsInteractive returns whether standard input is a terminal, so the user can be asked questions
+func IsInteractive() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultServerJavaArgs are the Java arguments used by server start scripts when JAVA_ARGS is not set
+const DefaultServerJavaArgs = "-Xmx4G"
+
+// GetServerLaunchArgs returns the arguments to pass to Java (after any JVM arguments) to start a server for the pack's
+// loader, for the given OS ("unix" or "win")
+func (pack Pack) GetServerLaunchArgs(osName string) (string, error) {
+	mcVersion, err := pack.GetMCVersion()
+	if err != nil {
+		return "", err
+	}
+
+	if forgeVersion, ok := pack.Versions["forge"]; ok {
+		if mcMinorVersionAtLeast(mcVersion, 17) {
+			// Forge 1.17+ is started using argument files created by the installer
+			return fmt.Sprintf("@libraries/net/minecraftforge/forge/%s-%s/%s_args.txt nogui", mcVersion, forgeVersion, osName), nil
+		} else if mcMinorVersionAtLeast(mcVersion, 12) {
+			return fmt.Sprintf("-jar forge-%s-%s.jar nogui", mcVersion, forgeVersion), nil
+		}
+		return fmt.Sprintf("-jar forge-%s-%s-universal.jar nogui", mcVersion, forgeVersion), nil
+	}
+	if neoForgeVersion, ok := pack.Versions["neoforge"]; ok {
+		// NeoForge for 1.20.1 used the same versioning as Forge
+		if mcVersion == "1.20.1" {
+			return fmt.Sprintf("@libraries/net/neoforged/forge/%s-%s/%s_args.txt nogui", mcVersion, neoForgeVersion, osName), nil
+		}
+		return fmt.Sprintf("@libraries/net/neoforged/neoforge/%s/%s_args.txt nogui", neoForgeVersion, osName), nil
+	}
+	if _, ok := pack.Versions["fabric"]; ok {
+		return "-jar fabric-server-launch.jar nogui", nil
+	}
+	if _, ok := pack.Versions["quilt"]; ok {
+		return "-jar quilt-server-launch.jar nogui", nil
+	}
+	if _, ok := pack.Versions["liteloader"]; ok {
+		return "", errors.New("server start scripts are not supported for LiteLoader packs")
+	}
+	return fmt.Sprintf("-jar minecraft_server.%s.jar nogui", mcVersion), nil
+}
+
+// GetServerStartScripts returns the contents of scripts to start a server for the pack (start.sh and start.bat), keyed
+// by file name
+func (pack Pack) GetServerStartScripts() (map[string]string, error) {
+	unixArgs, err := pack.GetServerLaunchArgs("unix")
+	if err != nil {
+		return nil, err
+	}
+	windowsArgs, err := pack.GetServerLaunchArgs("win")
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"start.sh": "#!/usr/bin/env sh\n" +
+			"# Set JAVA_ARGS to change the memory allocated to the server\n" +
+			"java ${JAVA_ARGS:-" + DefaultServerJavaArgs + "} " + unixArgs + " \"$@\"\n",
+		"start.bat": "@echo off\r\n" +
+			"rem Set JAVA_ARGS to change the memory allocated to the server\r\n" +
+			"if not defined JAVA_ARGS set JAVA_ARGS=" + DefaultServerJavaArgs + "\r\n" +
+			"java %JAVA_ARGS% " + windowsArgs + " %*\r\n" +
+			"pause\r\n",
+	}, nil
+}
+
+// mcMinorVersionAtLeast returns true if the given Minecraft version is 1.x where x is at least minor
+func mcMinorVersionAtLeast(mcVersion string, minor int) bool {
+	parts := strings.Split(mcVersion, ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return false
+	}
+	v, err := strconv.Atoi(parts[1])
+	return err == nil && v >= minor
+}
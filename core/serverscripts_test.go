@@ -0,0 +1,103 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetServerLaunchArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions map[string]string
+		osName   string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "vanilla",
+			versions: map[string]string{"minecraft": "1.18.2"},
+			osName:   "unix",
+			want:     "-jar minecraft_server.1.18.2.jar nogui",
+		},
+		{
+			name:     "forge 1.17+",
+			versions: map[string]string{"minecraft": "1.18.2", "forge": "40.1.0"},
+			osName:   "win",
+			want:     "@libraries/net/minecraftforge/forge/1.18.2-40.1.0/win_args.txt nogui",
+		},
+		{
+			name:     "forge 1.12",
+			versions: map[string]string{"minecraft": "1.12.2", "forge": "14.23.5.2860"},
+			osName:   "unix",
+			want:     "-jar forge-1.12.2-14.23.5.2860.jar nogui",
+		},
+		{
+			name:     "old forge",
+			versions: map[string]string{"minecraft": "1.7.10", "forge": "10.13.4.1614"},
+			osName:   "unix",
+			want:     "-jar forge-1.7.10-10.13.4.1614-universal.jar nogui",
+		},
+		{
+			name:     "neoforge 1.20.1",
+			versions: map[string]string{"minecraft": "1.20.1", "neoforge": "47.1.84"},
+			osName:   "unix",
+			want:     "@libraries/net/neoforged/forge/1.20.1-47.1.84/unix_args.txt nogui",
+		},
+		{
+			name:     "neoforge",
+			versions: map[string]string{"minecraft": "1.20.4", "neoforge": "20.4.80-beta"},
+			osName:   "win",
+			want:     "@libraries/net/neoforged/neoforge/20.4.80-beta/win_args.txt nogui",
+		},
+		{
+			name:     "fabric",
+			versions: map[string]string{"minecraft": "1.18.2", "fabric": "0.13.3"},
+			osName:   "unix",
+			want:     "-jar fabric-server-launch.jar nogui",
+		},
+		{
+			name:     "quilt",
+			versions: map[string]string{"minecraft": "1.18.2", "quilt": "0.16.0"},
+			osName:   "unix",
+			want:     "-jar quilt-server-launch.jar nogui",
+		},
+		{
+			name:     "liteloader",
+			versions: map[string]string{"minecraft": "1.12.2", "liteloader": "1.12.2-SNAPSHOT"},
+			osName:   "unix",
+			wantErr:  true,
+		},
+		{
+			name:     "no minecraft version",
+			versions: map[string]string{"fabric": "0.13.3"},
+			osName:   "unix",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack := Pack{Versions: tt.versions}
+			got, err := pack.GetServerLaunchArgs(tt.osName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetServerLaunchArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetServerLaunchArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetServerStartScripts(t *testing.T) {
+	pack := Pack{Versions: map[string]string{"minecraft": "1.18.2", "fabric": "0.13.3"}}
+	scripts, err := pack.GetServerStartScripts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(scripts["start.sh"], "java ${JAVA_ARGS:-"+DefaultServerJavaArgs+"} -jar fabric-server-launch.jar nogui \"$@\"\n") {
+		t.Errorf("start.sh does not start the server:\n%s", scripts["start.sh"])
+	}
+	if !strings.Contains(scripts["start.bat"], "java %JAVA_ARGS% -jar fabric-server-launch.jar nogui %*\r\n") {
+		t.Errorf("start.bat does not start the server:\n%s", scripts["start.bat"])
+	}
+}
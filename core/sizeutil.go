@@ -0,0 +1,17 @@
+package core
+
+import "fmt"
+
+// FormatFileSize formats a size in bytes as a human-readable string, e.g. "1.5 MiB"
+func FormatFileSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,24 @@
+package core
+
+import "testing"
+
+func TestFormatFileSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 0, want: "0 B"},
+		{size: 1023, want: "1023 B"},
+		{size: 1024, want: "1.0 KiB"},
+		{size: 1536, want: "1.5 KiB"},
+		{size: 5 * 1024 * 1024, want: "5.0 MiB"},
+		{size: 3 * 1024 * 1024 * 1024 / 2, want: "1.5 GiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatFileSize(tt.size); got != tt.want {
+				t.Errorf("FormatFileSize(%d) = %q, want %q", tt.size, got, tt.want)
+			}
+		})
+	}
+}
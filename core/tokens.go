@@ -0,0 +1,22 @@
+package core
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// GetServiceToken returns the API token configured for a service (e.g. "curseforge", "modrinth" or "github"), or an
+// empty string if there isn't one. The PACKWIZ_<SERVICE>_TOKEN environment variable takes precedence over the
+// <service>.token option in the packwiz config file.
+func GetServiceToken(service string) string {
+	if token := os.Getenv(serviceTokenEnvVar(service)); token != "" {
+		return token
+	}
+	return viper.GetString(strings.ToLower(service) + ".token")
+}
+
+func serviceTokenEnvVar(service string) string {
+	return "PACKWIZ_" + strings.ToUpper(strings.ReplaceAll(service, "-", "_")) + "_TOKEN"
+}
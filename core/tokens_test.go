@@ -0,0 +1,49 @@
+package core
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGetServiceToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   string
+		envVar    string
+		envToken  string
+		confToken string
+		want      string
+	}{
+		{name: "unset", service: "curseforge", envVar: "PACKWIZ_CURSEFORGE_TOKEN"},
+		{name: "config", service: "curseforge", envVar: "PACKWIZ_CURSEFORGE_TOKEN", confToken: "conf", want: "conf"},
+		{name: "environment overrides config", service: "modrinth", envVar: "PACKWIZ_MODRINTH_TOKEN", envToken: "env", confToken: "conf", want: "env"},
+		{name: "service name with dash", service: "my-host", envVar: "PACKWIZ_MY_HOST_TOKEN", envToken: "env", want: "env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serviceTokenEnvVar(tt.service); got != tt.envVar {
+				t.Errorf("serviceTokenEnvVar() = %q, want %q", got, tt.envVar)
+			}
+
+			oldEnv, hadEnv := os.LookupEnv(tt.envVar)
+			if err := os.Setenv(tt.envVar, tt.envToken); err != nil {
+				t.Fatal(err)
+			}
+			viper.Set(tt.service+".token", tt.confToken)
+			defer func() {
+				if hadEnv {
+					_ = os.Setenv(tt.envVar, oldEnv)
+				} else {
+					_ = os.Unsetenv(tt.envVar)
+				}
+				viper.Set(tt.service+".token", "")
+			}()
+
+			if got := GetServiceToken(tt.service); got != tt.want {
+				t.Errorf("GetServiceToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package core
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 )
@@ -37,7 +39,7 @@ var ModLoaders = map[string]ModLoaderComponent{
 	"forge": {
 		Name:              "forge",
 		FriendlyName:      "Forge",
-		VersionListGetter: FetchMavenVersionPrefixedListStrip("https://files.minecraftforge.net/maven/net/minecraftforge/forge/maven-metadata.xml", "Forge"),
+		VersionListGetter: FetchForgeRecommendedVersion("https://files.minecraftforge.net/net/minecraftforge/forge/promotions_slim.json", FetchMavenVersionPrefixedListStrip("https://files.minecraftforge.net/maven/net/minecraftforge/forge/maven-metadata.xml", "Forge")),
 	},
 	"liteloader": {
 		Name:              "liteloader",
@@ -108,6 +110,42 @@ func FetchMavenVersionPrefixedListStrip(url string, friendlyName string) func(mc
 	}
 }
 
+// FetchForgeRecommendedVersion wraps a version list getter for Forge, so the recommended version for the Minecraft version
+// (from Forge's promotions) is used instead of the latest version where there is one
+func FetchForgeRecommendedVersion(promotionsURL string, versionListGetter func(mcVersion string) ([]string, string, error)) func(mcVersion string) ([]string, string, error) {
+	return func(mcVersion string) ([]string, string, error) {
+		versions, latestVersion, err := versionListGetter(mcVersion)
+		if err != nil {
+			return nil, "", err
+		}
+
+		res, err := http.Get(promotionsURL)
+		if err != nil {
+			return nil, "", err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("failed to get Forge promotions: %s", res.Status)
+		}
+		var promotions struct {
+			Promos map[string]string `json:"promos"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&promotions)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read Forge promotions: %w", err)
+		}
+
+		if recommended, ok := promotions.Promos[mcVersion+"-recommended"]; ok {
+			for _, v := range versions {
+				if v == recommended {
+					return versions, recommended, nil
+				}
+			}
+		}
+		return versions, latestVersion, nil
+	}
+}
+
 func removeMcVersion(str string, mcVersion string) string {
 	components := strings.Split(str, "-")
 	newComponents := make([]string, 0)
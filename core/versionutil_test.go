@@ -0,0 +1,50 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchForgeRecommendedVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/promotions_slim.json":
+			_, _ = w.Write([]byte(`{"homepage": "https://files.minecraftforge.net/", "promos": {"1.16.5-latest": "36.2.39", "1.16.5-recommended": "36.2.34", "1.18.2-recommended": "40.0.1"}}`))
+		case "/invalid.json":
+			_, _ = w.Write([]byte(`<html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	versionListGetter := func(mcVersion string) ([]string, string, error) {
+		return []string{"36.2.34", "36.2.35", "36.2.39"}, "36.2.39", nil
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		mcVersion string
+		want      string
+		wantErr   bool
+	}{
+		{name: "recommended", path: "/promotions_slim.json", mcVersion: "1.16.5", want: "36.2.34"},
+		{name: "no recommended version", path: "/promotions_slim.json", mcVersion: "1.17.1", want: "36.2.39"},
+		{name: "recommended version not in list", path: "/promotions_slim.json", mcVersion: "1.18.2", want: "36.2.39"},
+		{name: "not found", path: "/missing.json", mcVersion: "1.16.5", wantErr: true},
+		{name: "invalid response", path: "/invalid.json", mcVersion: "1.16.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := FetchForgeRecommendedVersion(server.URL+tt.path, versionListGetter)(tt.mcVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchForgeRecommendedVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FetchForgeRecommendedVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
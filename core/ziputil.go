@@ -0,0 +1,36 @@
+package core
+
+import (
+	"archive/zip"
+	"io"
+	"sort"
+)
+
+// ExportZip wraps a zip.Writer used to export a pack. Entries are written without modification times, so that exporting
+// the same pack twice (with its files in the same order) produces identical archives.
+type ExportZip struct {
+	*zip.Writer
+}
+
+// NewExportZip creates an ExportZip writing to w
+func NewExportZip(w io.Writer) *ExportZip {
+	return &ExportZip{zip.NewWriter(w)}
+}
+
+// CreateExecutable adds a file to the archive with executable permissions (e.g. a shell script), returning a Writer for
+// its contents
+func (z *ExportZip) CreateExecutable(name string) (io.Writer, error) {
+	header := &zip.FileHeader{
+		Name:   name,
+		Method: zip.Deflate,
+	}
+	header.SetMode(0755)
+	return z.CreateHeader(header)
+}
+
+// SortModsForExport sorts mods by their destination path, so they are exported in a consistent order
+func SortModsForExport(mods []Mod) {
+	sort.SliceStable(mods, func(i, j int) bool {
+		return mods[i].GetDestFilePath() < mods[j].GetDestFilePath()
+	})
+}
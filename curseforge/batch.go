@@ -0,0 +1,98 @@
+package curseforge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// defaultBatchSize is the number of IDs sent in each request for batched lookups, as the API rejects or truncates
+// requests for too many mods/files at once; it can be changed with the curseforge.batch-size option
+const defaultBatchSize = 100
+
+// batchWorkers is the maximum number of batch requests made concurrently
+const batchWorkers = 4
+
+// chunkIDs splits a list of IDs into chunks no larger than the configured batch size
+func chunkIDs(ids []int) [][]int {
+	size := viper.GetInt("curseforge.batch-size")
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+
+	var chunks [][]int
+	for len(ids) > size {
+		chunks = append(chunks, ids[:size])
+		ids = ids[size:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
+
+// runChunked calls fn for each chunk index from 0 to n, with up to batchWorkers calls running at once. Errors from
+// all chunks are collected and returned together.
+func runChunked(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, batchWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			errs[i] = fn(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	var batchErr batchError
+	for _, err := range errs {
+		if err != nil {
+			batchErr = append(batchErr, err)
+		}
+	}
+	if len(batchErr) == 0 {
+		return nil
+	}
+	if len(batchErr) == 1 {
+		return batchErr[0]
+	}
+	return batchErr
+}
+
+// batchError contains the errors returned from multiple requests in a batched lookup
+type batchError []error
+
+func (e batchError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the errors match target
+func (e batchError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error that matches target
+func (e batchError) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
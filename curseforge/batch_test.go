@@ -0,0 +1,80 @@
+package curseforge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestChunkIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		ids       []int
+		batchSize int
+		want      [][]int
+	}{
+		{name: "no IDs", batchSize: 2},
+		{name: "fewer IDs than the batch size", ids: []int{1}, batchSize: 2, want: [][]int{{1}}},
+		{name: "exact multiple", ids: []int{1, 2, 3, 4}, batchSize: 2, want: [][]int{{1, 2}, {3, 4}}},
+		{name: "remainder", ids: []int{1, 2, 3, 4, 5}, batchSize: 2, want: [][]int{{1, 2}, {3, 4}, {5}}},
+		{name: "default batch size", ids: make([]int, defaultBatchSize+1), want: [][]int{make([]int, defaultBatchSize), {0}}},
+		{name: "invalid batch size", ids: []int{1, 2}, batchSize: -1, want: [][]int{{1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.batch-size", tt.batchSize)
+			defer viper.Set("curseforge.batch-size", 0)
+
+			if got := chunkIDs(tt.ids); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunkIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunChunked(t *testing.T) {
+	errTest := errors.New("test error")
+
+	tests := []struct {
+		name    string
+		failing map[int]bool
+		wantErr string
+	}{
+		{name: "no errors"},
+		{name: "one error", failing: map[int]bool{1: true}, wantErr: "chunk 1: test error"},
+		{name: "multiple errors", failing: map[int]bool{0: true, 2: true}, wantErr: "chunk 0: test error; chunk 2: test error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := make([]bool, 3)
+			err := runChunked(context.Background(), len(called), func(ctx context.Context, i int) error {
+				called[i] = true
+				if tt.failing[i] {
+					return fmt.Errorf("chunk %d: %w", i, errTest)
+				}
+				return nil
+			})
+			for i, c := range called {
+				if !c {
+					t.Errorf("chunk %d was not run", i)
+				}
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("runChunked() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("runChunked() error = %v, want %q", err, tt.wantErr)
+			}
+			if !errors.Is(err, errTest) {
+				t.Errorf("runChunked() error does not wrap the chunk errors")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package curseforge
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Cache stores API responses, so repeated lookups of the same mods/files don't need to query CurseForge again
+type Cache interface {
+	// Get returns the value stored for a key, and whether it was found (and has not expired)
+	Get(key string) ([]byte, bool)
+	// Set stores a value for a key, which expires after the given TTL
+	Set(key string, value []byte, ttl time.Duration)
+	// Delete removes the value stored for a key, if it exists
+	Delete(key string)
+}
+
+// cacheTTL is the duration responses are cached for
+const cacheTTL = 10 * time.Minute
+
+var responseCache Cache = NewMemoryCache()
+
+// SetCache replaces the cache used for CurseForge API responses
+func SetCache(c Cache) {
+	responseCache = c
+}
+
+type memoryCacheEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemoryCache is a Cache that stores values in memory, for the duration of a single run of packwiz
+type MemoryCache struct {
+	entries map[string]memoryCacheEntry
+	lock    sync.RWMutex
+}
+
+// NewMemoryCache creates a new, empty MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[key] = memoryCacheEntry{value, time.Now().Add(ttl)}
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, key)
+}
+
+// cacheGet decodes a cached JSON value into out, returning true if it was found; the cache is bypassed if the no-cache
+// option is set
+func cacheGet(key string, out interface{}) bool {
+	if viper.GetBool("no-cache") {
+		return false
+	}
+	data, ok := responseCache.Get(key)
+	if !ok {
+		return false
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		// Invalid cached data; remove it so it is queried again
+		responseCache.Delete(key)
+		return false
+	}
+	return true
+}
+
+// cacheSet encodes a value as JSON and stores it in the cache
+func cacheSet(key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	responseCache.Set(key, data, cacheTTL)
+}
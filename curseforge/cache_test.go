@@ -0,0 +1,54 @@
+package curseforge
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestCacheGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		noCache bool
+		want    bool
+	}{
+		{name: "cached", want: true},
+		{name: "no-cache set", noCache: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldCache := responseCache
+			SetCache(NewMemoryCache())
+			viper.Set("no-cache", tt.noCache)
+			defer func() {
+				SetCache(oldCache)
+				viper.Set("no-cache", false)
+			}()
+
+			cacheSet("addon/1", modInfo{ID: 1, Name: "Test"})
+			var got modInfo
+			if ok := cacheGet("addon/1", &got); ok != tt.want {
+				t.Fatalf("cacheGet() = %v, want %v", ok, tt.want)
+			}
+			if tt.want && got.Name != "Test" {
+				t.Errorf("cacheGet() decoded name %q, want %q", got.Name, "Test")
+			}
+		})
+	}
+}
+
+func TestCacheGetInvalidData(t *testing.T) {
+	oldCache := responseCache
+	cache := NewMemoryCache()
+	SetCache(cache)
+	defer SetCache(oldCache)
+
+	cache.Set("addon/1", []byte("not json"), cacheTTL)
+	var got modInfo
+	if cacheGet("addon/1", &got) {
+		t.Fatal("cacheGet() returned true for invalid data")
+	}
+	if _, ok := cache.Get("addon/1"); ok {
+		t.Error("invalid cached data was not removed")
+	}
+}
@@ -1,8 +1,12 @@
 package curseforge
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/spf13/viper"
+	"io/ioutil"
+	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
@@ -30,6 +34,10 @@ var fileIDRegexes = [...]*regexp.Regexp{
 	regexp.MustCompile("^https?://(?:www\\.)?curseforge\\.com/minecraft/mc-mods/(.+)/download/(\\d+)"),
 }
 
+// cdnURLRegex matches CurseForge CDN download URLs, which contain the file ID split into two parts (the last 3 digits,
+// without leading zeroes, and the rest of the ID)
+var cdnURLRegex = regexp.MustCompile("^https?://(?:edge|media|mediafilez)\\.forgecdn\\.net/files/(\\d+)/(\\d+)/[^/]+$")
+
 var snapshotVersionRegex = regexp.MustCompile("(?:Snapshot )?(\\d+)w0?(0|[1-9]\\d*)([a-z])")
 
 var snapshotNames = [...]string{"-pre", " Pre-Release ", " Pre-release ", "-rc"}
@@ -109,11 +117,19 @@ func getCurseforgeVersion(mcVersion string) string {
 	return mcVersion
 }
 
-func getFileIDsFromString(mod string) (bool, int, int, error) {
+func getFileIDsFromString(ctx context.Context, mod string) (bool, int, int, error) {
+	if fileID, ok := getFileIDFromCDNURL(mod); ok {
+		modID, err := getModIDFromCDNURL(ctx, mod, fileID)
+		if err != nil {
+			return true, 0, 0, err
+		}
+		return true, modID, fileID, nil
+	}
+
 	for _, v := range fileIDRegexes {
 		matches := v.FindStringSubmatch(mod)
 		if matches != nil && len(matches) == 3 {
-			modID, err := modIDFromSlug(matches[1])
+			modID, err := modIDFromSlug(ctx, matches[1])
 			if err != nil {
 				return true, 0, 0, err
 			}
@@ -127,6 +143,57 @@ func getFileIDsFromString(mod string) (bool, int, int, error) {
 	return false, 0, 0, nil
 }
 
+// getFileIDFromCDNURL gets the file ID from a CurseForge CDN download URL
+// e.g. https://edge.forgecdn.net/files/3456/789/mod.jar is file 3456789, and .../files/3456/7/mod.jar is file 3456007
+func getFileIDFromCDNURL(u string) (int, bool) {
+	matches := cdnURLRegex.FindStringSubmatch(u)
+	if matches == nil {
+		return 0, false
+	}
+	upper, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, false
+	}
+	lower, err := strconv.Atoi(matches[2])
+	if err != nil || lower >= 1000 {
+		return 0, false
+	}
+	return upper*1000 + lower, true
+}
+
+// getModIDFromCDNURL finds the mod that a file on the CurseForge CDN belongs to (as the URL doesn't contain the mod
+// ID), by downloading it and looking up its fingerprint. The response from looking up the file by its ID is keyed by the
+// file ID, so it can't be used to find the mod.
+func getModIDFromCDNURL(ctx context.Context, u string, fileID int) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download %s: %s", u, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := getFingerprintInfoWithRetry(ctx, []int{murmur2Fingerprint(data)})
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range res.ExactMatches {
+		if v.File.ID == fileID {
+			return v.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("failed to find the mod for file %d", fileID)
+}
+
 var modSlugRegexes = [...]*regexp.Regexp{
 	regexp.MustCompile("^https?://minecraft\\.curseforge\\.com/projects/([^/]+)"),
 	regexp.MustCompile("^https?://(?:www\\.)?curseforge\\.com/minecraft/mc-mods/([^/]+)"),
@@ -134,7 +201,7 @@ var modSlugRegexes = [...]*regexp.Regexp{
 	regexp.MustCompile("^[a-z][\\da-z\\-_]{0,127}$"),
 }
 
-func getModIDFromString(mod string) (bool, int, error) {
+func getModIDFromString(ctx context.Context, mod string) (bool, int, error) {
 	// Check if it's just a number first
 	modID, err := strconv.Atoi(mod)
 	if err == nil && modID > 0 {
@@ -152,7 +219,7 @@ func getModIDFromString(mod string) (bool, int, error) {
 			} else {
 				continue
 			}
-			modID, err := modIDFromSlug(slug)
+			modID, err := modIDFromSlug(ctx, slug)
 			if err != nil {
 				return true, 0, err
 			}
@@ -162,13 +229,20 @@ func getModIDFromString(mod string) (bool, int, error) {
 	return false, 0, nil
 }
 
-func createModFile(modInfo modInfo, fileInfo modFileInfo, index *core.Index, optionalDisabled bool) error {
+// createModFile writes the metadata file for a CurseForge file with the given side, and adds it to the index
+func createModFile(modInfo modInfo, fileInfo modFileInfo, index *core.Index, optionalDisabled bool, releaseChannel string, side string) error {
+	if fileInfo.DownloadURL == "" {
+		return fmt.Errorf("%w: the author of %s has disabled third-party downloads, so %s must be downloaded manually from %s",
+			ErrDownloadUnavailable, modInfo.Name, fileInfo.FileName, modInfo.WebsiteURL)
+	}
+
 	updateMap := make(map[string]map[string]interface{})
 	var err error
 
 	updateMap["curseforge"], err = cfUpdateData{
-		ProjectID: modInfo.ID,
-		FileID:    fileInfo.ID,
+		ProjectID:      modInfo.ID,
+		FileID:         fileInfo.ID,
+		ReleaseChannel: releaseChannel,
 	}.ToMap()
 	if err != nil {
 		return err
@@ -179,7 +253,10 @@ func createModFile(modInfo modInfo, fileInfo modFileInfo, index *core.Index, opt
 		return err
 	}
 
-	hash, hashFormat := fileInfo.getBestHash()
+	hash, hashFormat, ok := fileInfo.getBestHash()
+	if !ok {
+		return errors.New("no valid hash available for file " + fileInfo.FileName)
+	}
 
 	var optional *core.ModOption
 	if optionalDisabled {
@@ -192,7 +269,7 @@ func createModFile(modInfo modInfo, fileInfo modFileInfo, index *core.Index, opt
 	modMeta := core.Mod{
 		Name:     modInfo.Name,
 		FileName: fileInfo.FileName,
-		Side:     core.UniversalSide,
+		Side:     side,
 		Download: core.ModDownload{
 			URL:        u,
 			HashFormat: hashFormat,
@@ -216,53 +293,205 @@ func createModFile(modInfo modInfo, fileInfo modFileInfo, index *core.Index, opt
 	return index.RefreshFileWithHash(path, format, hash, true)
 }
 
+// modloaderTypeNames maps modloader types to the names CurseForge uses to tag files in GameVersions
+var modloaderTypeNames = map[int]string{
+	modloaderTypeForge:    "Forge",
+	modloaderTypeFabric:   "Fabric",
+	modloaderTypeQuilt:    "Quilt",
+	modloaderTypeNeoForge: "NeoForge",
+}
+
+// modloaderTypeComponents maps pack version components to modloader types
+var modloaderTypeComponents = map[string]int{
+	"forge":    modloaderTypeForge,
+	"fabric":   modloaderTypeFabric,
+	"quilt":    modloaderTypeQuilt,
+	"neoforge": modloaderTypeNeoForge,
+}
+
 func getLoader(pack core.Pack) int {
-	dependencies := pack.Versions
-
-	_, hasFabric := dependencies["fabric"]
-	_, hasForge := dependencies["forge"]
-	if hasFabric && hasForge {
-		return modloaderTypeAny
-	} else if hasFabric {
-		return modloaderTypeFabric
-	} else if hasForge {
-		return modloaderTypeForge
-	} else {
-		return modloaderTypeAny
+	loaderType := modloaderTypeAny
+	for component, componentLoaderType := range modloaderTypeComponents {
+		if _, ok := pack.Versions[component]; ok {
+			if loaderType != modloaderTypeAny {
+				// Multiple loaders - can't filter by loader
+				return modloaderTypeAny
+			}
+			loaderType = componentLoaderType
+		}
 	}
+	return loaderType
 }
 
-func matchLoaderType(packLoaderType int, modLoaderType int) bool {
+// getFallbackLoaderType returns a loader type that a pack with the given loader type can also load mods for (e.g.
+// Fabric mods on Quilt), and whether there is one
+func getFallbackLoaderType(packLoaderType int) (int, bool) {
+	if packLoaderType == modloaderTypeQuilt {
+		return modloaderTypeFabric, true
+	}
+	return modloaderTypeAny, false
+}
+
+func matchLoaderType(packLoaderType int, modLoaderType int, allowFallback bool) bool {
 	if packLoaderType == modloaderTypeAny || modLoaderType == modloaderTypeAny {
 		return true
 	} else {
+		if allowFallback {
+			if fallbackLoaderType, ok := getFallbackLoaderType(packLoaderType); ok && fallbackLoaderType == modLoaderType {
+				return true
+			}
+		}
 		return packLoaderType == modLoaderType
 	}
 }
 
-func matchLoaderTypeFileInfo(packLoaderType int, fileInfoData modFileInfo) bool {
+func matchLoaderTypeFileInfo(packLoaderType int, fileInfoData modFileInfo, allowFallback bool) bool {
 	if packLoaderType == modloaderTypeAny {
 		return true
 	} else {
-		if packLoaderType == modloaderTypeFabric {
-			for _, v := range fileInfoData.GameVersions {
-				if v == "Fabric" {
-					return true
-				}
+		loaderName, ok := modloaderTypeNames[packLoaderType]
+		if !ok {
+			return true
+		}
+		fallbackLoaderName := ""
+		if allowFallback {
+			if fallbackLoaderType, ok := getFallbackLoaderType(packLoaderType); ok {
+				fallbackLoaderName = modloaderTypeNames[fallbackLoaderType]
 			}
-		} else if packLoaderType == modloaderTypeForge {
-			for _, v := range fileInfoData.GameVersions {
-				if v == "Forge" {
-					return true
-				}
+		}
+		for _, v := range fileInfoData.getLoaderTags() {
+			if v == loaderName || (len(fallbackLoaderName) > 0 && v == fallbackLoaderName) {
+				return true
 			}
-		} else {
-			return true
 		}
 		return false
 	}
 }
 
+// Game version type IDs of tags that aren't Minecraft versions
+const (
+	gameVersionTypeModloader   = 68441
+	gameVersionTypeEnvironment = 75208
+)
+
+var environmentTagNames = map[string]bool{
+	"Client": true,
+	"Server": true,
+}
+
+// getLoaderTags returns the names of the modloaders that a file is tagged with
+func (i modFileInfo) getLoaderTags() []string {
+	if len(i.SortableGameVersions) > 0 {
+		return i.getTagsOfType(gameVersionTypeModloader)
+	}
+	// Older responses don't have type IDs, so the names must be compared instead
+	var tags []string
+	for _, v := range i.GameVersions {
+		for _, name := range modloaderTypeNames {
+			if v == name {
+				tags = append(tags, v)
+				break
+			}
+		}
+	}
+	return tags
+}
+
+// getEnvironmentTags returns the environments (Client and Server) that a file is tagged with
+func (i modFileInfo) getEnvironmentTags() []string {
+	if len(i.SortableGameVersions) > 0 {
+		return i.getTagsOfType(gameVersionTypeEnvironment)
+	}
+	var tags []string
+	for _, v := range i.GameVersions {
+		if environmentTagNames[v] {
+			tags = append(tags, v)
+		}
+	}
+	return tags
+}
+
+// getMCVersions returns the Minecraft versions that a file is tagged with, excluding loader, environment and Java
+// version tags
+func (i modFileInfo) getMCVersions() []string {
+	var versions []string
+	if len(i.SortableGameVersions) > 0 {
+		for _, v := range i.SortableGameVersions {
+			if v.TypeID != gameVersionTypeModloader && v.TypeID != gameVersionTypeEnvironment && !isJavaVersionTag(v.Name) {
+				versions = append(versions, v.Name)
+			}
+		}
+		return versions
+	}
+	loaderTags := make(map[string]bool)
+	for _, v := range i.getLoaderTags() {
+		loaderTags[v] = true
+	}
+	for _, v := range i.GameVersions {
+		if !loaderTags[v] && !environmentTagNames[v] && !isJavaVersionTag(v) {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// isJavaVersionTag returns true if the tag is a Java version requirement (e.g. "Java 17") rather than a Minecraft version
+func isJavaVersionTag(tag string) bool {
+	return strings.HasPrefix(tag, "Java ")
+}
+
+// getSideHint returns the side that a file is for, from the environments it is tagged with, or an empty string if it
+// isn't tagged with only one environment
+func (i modFileInfo) getSideHint() string {
+	hasClient := false
+	hasServer := false
+	for _, v := range i.getEnvironmentTags() {
+		if v == "Client" {
+			hasClient = true
+		} else if v == "Server" {
+			hasServer = true
+		}
+	}
+	if hasClient && !hasServer {
+		return core.ClientSide
+	} else if hasServer && !hasClient {
+		return core.ServerSide
+	}
+	return ""
+}
+
+// getInstallSide returns the side to install a file with: the given side if it is set, otherwise the environment the
+// file is tagged with, falling back to the default-side option
+func (i modFileInfo) getInstallSide(side string) (string, error) {
+	if side == "" {
+		side = i.getSideHint()
+	}
+	return core.GetDefaultSide(side)
+}
+
+// getImportSide returns the side to import a file with: the environment the file is tagged with, or both sides
+func (i modFileInfo) getImportSide() string {
+	if side := i.getSideHint(); side != "" {
+		return side
+	}
+	return core.UniversalSide
+}
+
+func (i modFileInfo) getTagsOfType(typeID int) []string {
+	var tags []string
+	for _, v := range i.SortableGameVersions {
+		if v.TypeID == typeID {
+			tags = append(tags, v.Name)
+		}
+	}
+	return tags
+}
+
+// isClientOnlyFile returns true if the file is tagged with the Client environment and not the Server environment
+func isClientOnlyFile(fileInfoData modFileInfo) bool {
+	return fileInfoData.getSideHint() == core.ClientSide
+}
+
 func matchGameVersion(mcVersion string, modMcVersion string) bool {
 	if getCurseforgeVersion(mcVersion) == modMcVersion {
 		return true
@@ -294,6 +523,8 @@ func matchGameVersions(mcVersion string, modMcVersions []string) bool {
 type cfUpdateData struct {
 	ProjectID int `mapstructure:"project-id"`
 	FileID    int `mapstructure:"file-id"`
+	// ReleaseChannel overrides the pack-wide release channel for this mod, if set
+	ReleaseChannel string `mapstructure:"release-channel,omitempty"`
 }
 
 func (u cfUpdateData) ToMap() (map[string]interface{}, error) {
@@ -317,7 +548,18 @@ type cachedStateStore struct {
 	fileInfo    modFileInfo
 }
 
-func (u cfUpdater) CheckUpdate(mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+func (u cfUpdater) CheckUpdate(ctx context.Context, mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+	return checkLatestFiles(ctx, mods, mcVersion, pack, true)
+}
+
+// SelectFiles finds the latest compatible file of each mod, whether or not it is newer than the installed file
+func (u cfUpdater) SelectFiles(ctx context.Context, mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+	return checkLatestFiles(ctx, mods, mcVersion, pack, false)
+}
+
+// checkLatestFiles finds the latest compatible file of each mod; if onlyNewer is true, files that aren't newer than the
+// installed file are not returned as updates
+func checkLatestFiles(ctx context.Context, mods []core.Mod, mcVersion string, pack core.Pack, onlyNewer bool) ([]core.UpdateCheck, error) {
 	results := make([]core.UpdateCheck, len(mods))
 	modIDs := make([]int, len(mods))
 	modInfos := make([]modInfo, len(mods))
@@ -332,7 +574,7 @@ func (u cfUpdater) CheckUpdate(mods []core.Mod, mcVersion string, pack core.Pack
 		modIDs[i] = project.ProjectID
 	}
 
-	modInfosUnsorted, err := getModInfoMultiple(modIDs)
+	modInfosUnsorted, err := getModInfoMultiple(ctx, modIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -355,61 +597,36 @@ func (u cfUpdater) CheckUpdate(mods []core.Mod, mcVersion string, pack core.Pack
 		}
 		project := projectRaw.(cfUpdateData)
 
-		updateAvailable := false
-		fileID := project.FileID
-		fileInfoObtained := false
-		var fileInfoData modFileInfo
-		var fileName string
-
-		// For snapshots, curseforge doesn't put them in GameVersionLatestFiles
-		for _, v := range modInfos[i].LatestFiles {
-			// Choose "newest" version by largest ID
-			if matchGameVersions(mcVersion, v.GameVersions) && v.ID > fileID && matchLoaderTypeFileInfo(packLoaderType, v) {
-				updateAvailable = true
-				fileID = v.ID
-				fileInfoData = v
-				fileInfoObtained = true
-				fileName = v.FileName
-			}
+		channel, err := getReleaseChannel(project.ReleaseChannel)
+		if err != nil {
+			results[i] = core.UpdateCheck{Error: err}
+			continue
 		}
 
-		for _, file := range modInfos[i].GameVersionLatestFiles {
-			// TODO: change to timestamp-based comparison??
-			// TODO: manage alpha/beta/release correctly, check update channel?
-			// Choose "newest" version by largest ID
-			if matchGameVersion(mcVersion, file.GameVersion) && file.ID > fileID && matchLoaderType(packLoaderType, file.Modloader) {
-				updateAvailable = true
-				fileID = file.ID
-				fileName = file.Name
-				fileInfoObtained = false // Make sure we get the file info again
+		latest, err := findLatestFileWithAllFiles(ctx, modInfos[i], mcVersion, packLoaderType, channel)
+		if err != nil {
+			if !onlyNewer && !errors.Is(err, errNoFileAvailable) {
+				results[i] = core.UpdateCheck{Error: err}
+				continue
 			}
-		}
-
-		if !updateAvailable {
 			results[i] = core.UpdateCheck{UpdateAvailable: false}
 			continue
 		}
-
-		// The API also provides some files inline, because that's efficient!
-		if !fileInfoObtained {
-			for _, file := range modInfos[i].LatestFiles {
-				if file.ID == fileID {
-					fileInfoObtained = true
-					fileInfoData = file
-				}
-			}
+		if onlyNewer && latest.fileID <= project.FileID {
+			results[i] = core.UpdateCheck{UpdateAvailable: false}
+			continue
 		}
 
 		results[i] = core.UpdateCheck{
 			UpdateAvailable: true,
-			UpdateString:    v.FileName + " -> " + fileName,
-			CachedState:     cachedStateStore{modInfos[i], fileInfoObtained, fileID, fileInfoData},
+			UpdateString:    v.FileName + " -> " + latest.fileName,
+			CachedState:     cachedStateStore{modInfos[i], latest.hasFileInfo, latest.fileID, latest.fileInfo},
 		}
 	}
 	return results, nil
 }
 
-func (u cfUpdater) DoUpdate(mods []*core.Mod, cachedState []interface{}) error {
+func (u cfUpdater) DoUpdate(ctx context.Context, mods []*core.Mod, cachedState []interface{}) error {
 	// "Do" isn't really that accurate, more like "Apply", because all the work is done in CheckUpdate!
 	for i, v := range mods {
 		modState := cachedState[i].(cachedStateStore)
@@ -417,7 +634,7 @@ func (u cfUpdater) DoUpdate(mods []*core.Mod, cachedState []interface{}) error {
 		fileInfoData := modState.fileInfo
 		if !modState.hasFileInfo {
 			var err error
-			fileInfoData, err = getFileInfo(modState.ID, modState.fileID)
+			fileInfoData, err = getFileInfo(ctx, modState.ID, modState.fileID)
 			if err != nil {
 				return err
 			}
@@ -430,7 +647,10 @@ func (u cfUpdater) DoUpdate(mods []*core.Mod, cachedState []interface{}) error {
 
 		v.FileName = fileInfoData.FileName
 		v.Name = modState.Name
-		hash, hashFormat := fileInfoData.getBestHash()
+		hash, hashFormat, ok := fileInfoData.getBestHash()
+		if !ok {
+			return errors.New("no valid hash available for file " + fileInfoData.FileName)
+		}
 		v.Download = core.ModDownload{
 			URL:        u,
 			HashFormat: hashFormat,
@@ -444,6 +664,142 @@ func (u cfUpdater) DoUpdate(mods []*core.Mod, cachedState []interface{}) error {
 	return nil
 }
 
+// MigrateMetadata replaces murmur2 fingerprints stored as the hash of a mod (by older versions of packwiz) with SHA1
+// hashes, where CurseForge provides them
+func (u cfUpdater) MigrateMetadata(ctx context.Context, mods []*core.Mod) ([]bool, error) {
+	changed := make([]bool, len(mods))
+	var fileIDs []int
+	for _, v := range mods {
+		if v.Download.HashFormat != "murmur2" {
+			continue
+		}
+		projectRaw, ok := v.GetParsedUpdateData("curseforge")
+		if !ok {
+			continue
+		}
+		fileIDs = append(fileIDs, projectRaw.(cfUpdateData).FileID)
+	}
+	if len(fileIDs) == 0 {
+		return changed, nil
+	}
+
+	fileInfosUnsorted, err := getFileInfoMultiple(ctx, fileIDs)
+	if err != nil {
+		return changed, err
+	}
+	fileInfos := make(map[int]modFileInfo)
+	for _, v := range fileInfosUnsorted {
+		for _, file := range v {
+			fileInfos[file.ID] = file
+		}
+	}
+
+	for i, v := range mods {
+		if v.Download.HashFormat != "murmur2" {
+			continue
+		}
+		projectRaw, ok := v.GetParsedUpdateData("curseforge")
+		if !ok {
+			continue
+		}
+		fileInfoData, ok := fileInfos[projectRaw.(cfUpdateData).FileID]
+		if !ok {
+			continue
+		}
+		hash, hashFormat, ok := fileInfoData.getBestHash()
+		if ok && hashFormat == "sha1" {
+			v.Download.Hash = hash
+			v.Download.HashFormat = hashFormat
+			changed[i] = true
+		}
+	}
+	return changed, nil
+}
+
+func (u cfUpdater) ListDependencies(ctx context.Context, mods []core.Mod) ([]string, [][]string, error) {
+	projectIDs := make([]string, len(mods))
+	deps := make([][]string, len(mods))
+	var fileIDs []int
+	for i, v := range mods {
+		projectRaw, ok := v.GetParsedUpdateData("curseforge")
+		if !ok {
+			continue
+		}
+		project := projectRaw.(cfUpdateData)
+		projectIDs[i] = strconv.Itoa(project.ProjectID)
+		fileIDs = append(fileIDs, project.FileID)
+	}
+	if len(fileIDs) == 0 {
+		return projectIDs, deps, nil
+	}
+
+	fileInfosUnsorted, err := getFileInfoMultiple(ctx, fileIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileInfos := make(map[int]modFileInfo)
+	for _, v := range fileInfosUnsorted {
+		for _, file := range v {
+			fileInfos[file.ID] = file
+		}
+	}
+
+	for i, v := range mods {
+		projectRaw, ok := v.GetParsedUpdateData("curseforge")
+		if !ok {
+			continue
+		}
+		fileInfoData, ok := fileInfos[projectRaw.(cfUpdateData).FileID]
+		if !ok {
+			continue
+		}
+		for _, dep := range fileInfoData.Dependencies {
+			if dep.Type == dependencyTypeRequired {
+				deps[i] = append(deps[i], strconv.Itoa(dep.ModID))
+			}
+		}
+	}
+	return projectIDs, deps, nil
+}
+
+// ResolveLicenses finds what is known about the licenses of mods from their CurseForge projects. CurseForge doesn't
+// provide license identifiers, so only projects that don't allow redistribution are given a license (a custom SPDX
+// reference); the license of other projects is unknown.
+func (u cfUpdater) ResolveLicenses(ctx context.Context, mods []core.Mod) ([]string, error) {
+	licenses := make([]string, len(mods))
+	var projectIDs []int
+	for _, v := range mods {
+		projectRaw, ok := v.GetParsedUpdateData("curseforge")
+		if !ok {
+			continue
+		}
+		projectIDs = append(projectIDs, projectRaw.(cfUpdateData).ProjectID)
+	}
+	if len(projectIDs) == 0 {
+		return licenses, nil
+	}
+
+	modInfos, err := getModInfoMultiple(ctx, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+	allowDistribution := make(map[int]*bool)
+	for _, v := range modInfos {
+		allowDistribution[v.ID] = v.AllowModDistribution
+	}
+
+	for i, v := range mods {
+		projectRaw, ok := v.GetParsedUpdateData("curseforge")
+		if !ok {
+			continue
+		}
+		if allowed := allowDistribution[projectRaw.(cfUpdateData).ProjectID]; allowed != nil && !*allowed {
+			licenses[i] = "LicenseRef-CurseForge-No-Redistribution"
+		}
+	}
+	return licenses, nil
+}
+
 type cfExportData struct {
 	ProjectID int `mapstructure:"project-id"`
 }
@@ -0,0 +1,373 @@
+package curseforge
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+	"github.com/spf13/viper"
+)
+
+func TestGetInstallSide(t *testing.T) {
+	clientFile := modFileInfo{GameVersions: []string{"1.18.2", "Client"}}
+	untaggedFile := modFileInfo{GameVersions: []string{"1.18.2"}}
+
+	tests := []struct {
+		name        string
+		file        modFileInfo
+		side        string
+		defaultSide string
+		want        string
+		wantErr     bool
+	}{
+		{name: "untagged file", file: untaggedFile, want: core.UniversalSide},
+		{name: "untagged file with default side", file: untaggedFile, defaultSide: core.ServerSide, want: core.ServerSide},
+		{name: "tagged file", file: clientFile, defaultSide: core.ServerSide, want: core.ClientSide},
+		{name: "given side", file: clientFile, side: core.ServerSide, want: core.ServerSide},
+		{name: "invalid side", file: untaggedFile, side: "neither", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("default-side", tt.defaultSide)
+			defer viper.Set("default-side", "")
+
+			got, err := tt.file.getInstallSide(tt.side)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getInstallSide() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getInstallSide() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetImportSide(t *testing.T) {
+	viper.Set("default-side", core.ServerSide)
+	defer viper.Set("default-side", "")
+
+	tests := []struct {
+		name string
+		file modFileInfo
+		want string
+	}{
+		{name: "untagged file ignores default side", file: modFileInfo{GameVersions: []string{"1.18.2"}}, want: core.UniversalSide},
+		{name: "tagged file", file: modFileInfo{GameVersions: []string{"1.18.2", "Client"}}, want: core.ClientSide},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.file.getImportSide(); got != tt.want {
+				t.Errorf("getImportSide() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateModFileSide(t *testing.T) {
+	dir := t.TempDir()
+	indexFile := filepath.Join(dir, "index.toml")
+	if err := ioutil.WriteFile(indexFile, []byte("hash-format = \"sha256\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index, err := core.LoadIndex(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("mods-folder", "mods")
+	defer viper.Set("mods-folder", "")
+
+	info := modInfo{ID: 1, Name: "Test Mod", Slug: "test-mod"}
+	file := modFileInfo{ID: 2, FileName: "test-mod.jar", DownloadURL: "https://edge.forgecdn.net/files/0/2/test-mod.jar", Fingerprint: 1234}
+	if err := createModFile(info, file, &index, false, "", core.ServerSide); err != nil {
+		t.Fatal(err)
+	}
+
+	mod, err := core.LoadMod(filepath.Join(dir, "mods", "test-mod"+core.ModExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mod.Side != core.ServerSide {
+		t.Errorf("createModFile() wrote side %q, want %q", mod.Side, core.ServerSide)
+	}
+}
+
+func TestGetMCVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		file modFileInfo
+		want []string
+	}{
+		{
+			name: "typed tags",
+			file: modFileInfo{SortableGameVersions: []sortableGameVersion{
+				{Name: "1.18.2", TypeID: 73250},
+				{Name: "Fabric", TypeID: gameVersionTypeModloader},
+				{Name: "Client", TypeID: gameVersionTypeEnvironment},
+				{Name: "1.18.1", TypeID: 73250},
+			}},
+			want: []string{"1.18.2", "1.18.1"},
+		},
+		{
+			name: "untyped tags",
+			file: modFileInfo{GameVersions: []string{"Forge", "1.16.5", "Server"}},
+			want: []string{"1.16.5"},
+		},
+		{
+			name: "typed Java version tags",
+			file: modFileInfo{SortableGameVersions: []sortableGameVersion{
+				{Name: "1.19", TypeID: 73407},
+				{Name: "Java 17", TypeID: 8},
+			}},
+			want: []string{"1.19"},
+		},
+		{
+			name: "untyped Java version tags",
+			file: modFileInfo{GameVersions: []string{"Java 17", "1.19", "Fabric"}},
+			want: []string{"1.19"},
+		},
+		{
+			name: "only loader tags",
+			file: modFileInfo{GameVersions: []string{"Forge", "Fabric"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.file.getMCVersions(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getMCVersions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSideHint(t *testing.T) {
+	tests := []struct {
+		name string
+		file modFileInfo
+		want string
+	}{
+		{name: "no environment tags", file: modFileInfo{GameVersions: []string{"1.18.2", "Fabric"}}, want: ""},
+		{name: "client only", file: modFileInfo{GameVersions: []string{"1.18.2", "Client"}}, want: core.ClientSide},
+		{name: "server only", file: modFileInfo{GameVersions: []string{"Server", "1.18.2"}}, want: core.ServerSide},
+		{name: "both environments", file: modFileInfo{GameVersions: []string{"Client", "Server"}}, want: ""},
+		{
+			name: "typed tags",
+			file: modFileInfo{SortableGameVersions: []sortableGameVersion{
+				{Name: "1.18.2", TypeID: 73250},
+				{Name: "Server", TypeID: gameVersionTypeEnvironment},
+			}},
+			want: core.ServerSide,
+		},
+		{
+			// With typed tags, only tags of the environment type are used
+			name: "typed tags with untyped environment names",
+			file: modFileInfo{
+				GameVersions:         []string{"Client"},
+				SortableGameVersions: []sortableGameVersion{{Name: "1.18.2", TypeID: 73250}},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.file.getSideHint(); got != tt.want {
+				t.Errorf("getSideHint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFileIDFromCDNURL(t *testing.T) {
+	tests := []struct {
+		url    string
+		wantID int
+		wantOk bool
+	}{
+		{url: "https://edge.forgecdn.net/files/3456/789/mod.jar", wantID: 3456789, wantOk: true},
+		{url: "https://edge.forgecdn.net/files/3456/7/mod.jar", wantID: 3456007, wantOk: true},
+		{url: "https://mediafilez.forgecdn.net/files/4000/12/mod%20name.jar", wantID: 4000012, wantOk: true},
+		{url: "https://edge.forgecdn.net/files/3456/1000/mod.jar"},
+		{url: "https://cdn.modrinth.com/data/AANobbMI/versions/1.0.0/mod.jar"},
+		{url: "not a url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			id, ok := getFileIDFromCDNURL(tt.url)
+			if id != tt.wantID || ok != tt.wantOk {
+				t.Errorf("getFileIDFromCDNURL() = %d, %v, want %d, %v", id, ok, tt.wantID, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestGetLoader(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions map[string]string
+		want     int
+	}{
+		{name: "vanilla", versions: map[string]string{"minecraft": "1.18.2"}, want: modloaderTypeAny},
+		{name: "quilt", versions: map[string]string{"minecraft": "1.18.2", "quilt": "0.16.0"}, want: modloaderTypeQuilt},
+		{name: "neoforge", versions: map[string]string{"minecraft": "1.20.4", "neoforge": "20.4.80-beta"}, want: modloaderTypeNeoForge},
+		{name: "multiple loaders", versions: map[string]string{"minecraft": "1.18.2", "fabric": "0.13.3", "forge": "40.1.0"}, want: modloaderTypeAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getLoader(core.Pack{Versions: tt.versions}); got != tt.want {
+				t.Errorf("getLoader() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchLoaderTypeFileInfo(t *testing.T) {
+	fabricFile := modFileInfo{GameVersions: []string{"1.18.2", "Fabric"}}
+	quiltFile := modFileInfo{GameVersions: []string{"1.18.2", "Quilt"}}
+
+	tests := []struct {
+		name          string
+		packLoader    int
+		file          modFileInfo
+		fileLoader    int
+		allowFallback bool
+		want          bool
+	}{
+		{name: "any loader", packLoader: modloaderTypeAny, file: fabricFile, fileLoader: modloaderTypeFabric, want: true},
+		{name: "same loader", packLoader: modloaderTypeQuilt, file: quiltFile, fileLoader: modloaderTypeQuilt, want: true},
+		{name: "fabric file on quilt", packLoader: modloaderTypeQuilt, file: fabricFile, fileLoader: modloaderTypeFabric, allowFallback: true, want: true},
+		{name: "fabric file on quilt without fallback", packLoader: modloaderTypeQuilt, file: fabricFile, fileLoader: modloaderTypeFabric, want: false},
+		{name: "quilt file on fabric", packLoader: modloaderTypeFabric, file: quiltFile, fileLoader: modloaderTypeQuilt, allowFallback: true, want: false},
+		{name: "fabric file on neoforge", packLoader: modloaderTypeNeoForge, file: fabricFile, fileLoader: modloaderTypeFabric, allowFallback: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchLoaderTypeFileInfo(tt.packLoader, tt.file, tt.allowFallback); got != tt.want {
+				t.Errorf("matchLoaderTypeFileInfo() = %v, want %v", got, tt.want)
+			}
+			// GameVersionLatestFiles entries have a single loader type instead of tags
+			if got := matchLoaderType(tt.packLoader, tt.fileLoader, tt.allowFallback); got != tt.want {
+				t.Errorf("matchLoaderType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetModIDFromCDNURL(t *testing.T) {
+	content := []byte("mod file contents")
+	server := newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/files/3456/789/mod.jar", "/files/3456/790/copy.jar":
+			_, _ = w.Write(content)
+		case "/fingerprint":
+			var hashes []int
+			if err := json.NewDecoder(req.Body).Decode(&hashes); err != nil {
+				t.Error(err)
+			}
+			res := addonFingerprintResponse{IsCacheBuilt: true}
+			if len(hashes) == 1 && hashes[0] == murmur2Fingerprint(content) {
+				res.ExactMatches = []addonFingerprintMatch{{ID: 1234, File: modFileInfo{ID: 3456789}}}
+			}
+			writeTestJSON(t, w, res)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	tests := []struct {
+		name      string
+		path      string
+		fileID    int
+		wantModID int
+		wantErr   bool
+	}{
+		{name: "file found", path: "/files/3456/789/mod.jar", fileID: 3456789, wantModID: 1234},
+		{name: "fingerprint of a different file", path: "/files/3456/790/copy.jar", fileID: 3456790, wantErr: true},
+		{name: "download fails", path: "/files/3456/1/missing.jar", fileID: 3456001, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileID, ok := getFileIDFromCDNURL("https://edge.forgecdn.net" + tt.path)
+			if !ok || fileID != tt.fileID {
+				t.Fatalf("getFileIDFromCDNURL() = %d, %v, want %d", fileID, ok, tt.fileID)
+			}
+			modID, err := getModIDFromCDNURL(context.Background(), server.URL+tt.path, fileID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getModIDFromCDNURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if modID != tt.wantModID {
+				t.Errorf("getModIDFromCDNURL() = %d, want %d", modID, tt.wantModID)
+			}
+		})
+	}
+}
+
+// loadTestMod writes metadata for a CurseForge mod with the given download hash and file ID, and loads it
+func loadTestMod(t *testing.T, name string, hashFormat string, hash string, fileID int) core.Mod {
+	t.Helper()
+	modFile := filepath.Join(t.TempDir(), name+core.ModExtension)
+	metadata := "name = \"" + name + "\"\nfilename = \"" + name + ".jar\"\n\n[download]\nurl = \"https://edge.forgecdn.net/files/" + name +
+		".jar\"\nhash-format = \"" + hashFormat + "\"\nhash = \"" + hash + "\"\n\n[update.curseforge]\nproject-id = 1\nfile-id = " + strconv.Itoa(fileID) + "\n"
+	if err := ioutil.WriteFile(modFile, []byte(metadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mod, err := core.LoadMod(modFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mod
+}
+
+func TestMigrateMetadata(t *testing.T) {
+	const sha1Hash = "da39a3ee5e6b4b0d3255bfef95601890afd80709"
+	var requested []int
+	newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/addon/files" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var fileIDs []int
+		if err := json.NewDecoder(req.Body).Decode(&fileIDs); err != nil {
+			t.Error(err)
+		}
+		requested = append(requested, fileIDs...)
+		var res map[string][]modFileInfo
+		err := json.Unmarshal([]byte(`{
+			"100": [{"id": 100, "packageFingerprint": 1234, "hashes": [{"value": "`+sha1Hash+`", "algorithm": 1}]}],
+			"200": [{"id": 200, "packageFingerprint": 5678, "hashes": [{"value": "d41d8cd98f00b204e9800998ecf8427e", "algorithm": 2}]}]
+		}`), &res)
+		if err != nil {
+			t.Error(err)
+		}
+		writeTestJSON(t, w, res)
+	}))
+
+	mods := []core.Mod{
+		loadTestMod(t, "legacy", "murmur2", "1234", 100),
+		loadTestMod(t, "legacy-without-sha1", "murmur2", "5678", 200),
+		loadTestMod(t, "current", "sha1", sha1Hash, 300),
+	}
+	changed, err := cfUpdater{}.MigrateMetadata(context.Background(), []*core.Mod{&mods[0], &mods[1], &mods[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(changed, []bool{true, false, false}) {
+		t.Errorf("MigrateMetadata() = %v, want only the legacy mod to be changed", changed)
+	}
+	if mods[0].Download.HashFormat != "sha1" || mods[0].Download.Hash != sha1Hash {
+		t.Errorf("legacy mod has a %s hash of %s, want a sha1 hash of %s", mods[0].Download.HashFormat, mods[0].Download.Hash, sha1Hash)
+	}
+	if mods[1].Download.HashFormat != "murmur2" || mods[1].Download.Hash != "5678" {
+		t.Errorf("mod without a SHA1 hash was changed to a %s hash of %s", mods[1].Download.HashFormat, mods[1].Download.Hash)
+	}
+	sort.Ints(requested)
+	if !reflect.DeepEqual(requested, []int{100, 200}) {
+		t.Errorf("requested files %v, want only the files of mods with murmur2 hashes", requested)
+	}
+}
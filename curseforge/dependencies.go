@@ -0,0 +1,177 @@
+package curseforge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+type installableDep struct {
+	modInfo
+	fileInfo modFileInfo
+}
+
+type dependencyResolution struct {
+	// required contains the required dependencies (and their dependencies) that need to be installed
+	required []installableDep
+	// optional contains optional dependencies that are not installed; these are not installed automatically
+	optional []modInfo
+	// hasRequired is true if there were any required dependencies, including ones that are already installed
+	hasRequired bool
+	// truncated is true if there were required dependencies deeper than the maximum depth, which were not resolved
+	truncated bool
+	// cycles contains a description of each circular dependency that was found (e.g. "A -> B -> A")
+	cycles []string
+}
+
+// resolveDependencies recursively finds the dependencies of the given file of a mod, skipping mods that are already in
+// the pack.
+// Required dependencies are resolved to a file compatible with the given Minecraft version and loader; optional
+// dependencies of the file and any of its required dependencies are only reported. If maxDepth is greater than 0, only
+// dependencies up to that many levels deep are resolved (1 being the direct dependencies of the file). Circular
+// dependencies are only followed once, and are reported.
+func resolveDependencies(ctx context.Context, modInfoData modInfo, fileInfoData modFileInfo, index core.Index, mcVersion string, packLoaderType int, channel int, maxDepth int) (dependencyResolution, error) {
+	var res dependencyResolution
+
+	// Mods that are installed, already resolved or queued; this also prevents dependency cycles from being followed
+	seen := make(map[int]bool)
+	for _, id := range getInstalledProjectIDs(index) {
+		seen[id] = true
+	}
+	seen[modInfoData.ID] = true
+
+	// The mod that first required each dependency, used to find the chain of dependencies that forms a cycle
+	requiredBy := make(map[int]int)
+	names := map[int]string{modInfoData.ID: modInfoData.Name}
+	var foundCycles [][]int
+
+	optionalSeen := make(map[int]bool)
+	var optionalIDs []int
+	var depIDPendingQueue []int
+	queueDeps := func(fromID int, file modFileInfo) {
+		for _, dep := range file.Dependencies {
+			switch dep.Type {
+			case dependencyTypeRequired:
+				res.hasRequired = true
+				if !seen[dep.ModID] {
+					seen[dep.ModID] = true
+					requiredBy[dep.ModID] = fromID
+					depIDPendingQueue = append(depIDPendingQueue, dep.ModID)
+				} else if cycle, ok := findDependencyCycle(requiredBy, fromID, dep.ModID); ok {
+					foundCycles = append(foundCycles, cycle)
+				}
+			case dependencyTypeOptional:
+				if !optionalSeen[dep.ModID] {
+					optionalSeen[dep.ModID] = true
+					optionalIDs = append(optionalIDs, dep.ModID)
+				}
+			}
+		}
+	}
+	queueDeps(modInfoData.ID, fileInfoData)
+
+	// As each mod is only queued once, resolution always ends, so the depth isn't limited unless maxDepth is set
+	depth := 0
+	for len(depIDPendingQueue) > 0 {
+		if maxDepth > 0 && depth >= maxDepth {
+			res.truncated = true
+			break
+		}
+		depth++
+
+		depInfoData, err := getModInfoMultiple(ctx, depIDPendingQueue)
+		if err != nil {
+			return res, fmt.Errorf("error retrieving dependency data: %w", err)
+		}
+		depIDPendingQueue = depIDPendingQueue[:0]
+
+		for _, currData := range depInfoData {
+			names[currData.ID] = currData.Name
+			depFileInfo, err := getLatestFile(ctx, currData, mcVersion, 0, packLoaderType, channel)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error retrieving dependency data for %s: %s\n", currData.Name, err.Error())
+				continue
+			}
+
+			queueDeps(currData.ID, depFileInfo)
+			res.required = append(res.required, installableDep{
+				currData, depFileInfo,
+			})
+		}
+	}
+
+	for _, cycle := range foundCycles {
+		desc := ""
+		for i, id := range cycle {
+			if i > 0 {
+				desc += " -> "
+			}
+			if name, ok := names[id]; ok {
+				desc += name
+			} else {
+				desc += strconv.Itoa(id)
+			}
+		}
+		res.cycles = append(res.cycles, desc)
+	}
+
+	// Optional dependencies that are installed, or are required by something else, don't need to be reported
+	var optionalPending []int
+	for _, id := range optionalIDs {
+		if !seen[id] {
+			optionalPending = append(optionalPending, id)
+		}
+	}
+	if len(optionalPending) > 0 {
+		optionalInfoData, err := getModInfoMultiple(ctx, optionalPending)
+		if err != nil {
+			return res, fmt.Errorf("error retrieving optional dependency data: %w", err)
+		}
+		res.optional = optionalInfoData
+	}
+
+	return res, nil
+}
+
+// findDependencyCycle returns the chain of mods from depID to fromID and back to depID, if fromID (a mod being resolved)
+// depends on depID through the chain of mods that required it
+func findDependencyCycle(requiredBy map[int]int, fromID int, depID int) ([]int, bool) {
+	chain := []int{fromID}
+	curr := fromID
+	for curr != depID {
+		parent, ok := requiredBy[curr]
+		if !ok {
+			return nil, false
+		}
+		chain = append(chain, parent)
+		curr = parent
+	}
+	// Reverse the chain so it starts from depID
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return append(chain, depID), true
+}
+
+// getInstalledProjectIDs returns the CurseForge project IDs of all mods in the index
+func getInstalledProjectIDs(index core.Index) []int {
+	var installedIDList []int
+	for _, modPath := range index.GetAllMods() {
+		mod, err := core.LoadMod(modPath)
+		if err == nil {
+			data, ok := mod.GetParsedUpdateData("curseforge")
+			if ok {
+				updateData, ok := data.(cfUpdateData)
+				if ok {
+					if updateData.ProjectID > 0 {
+						installedIDList = append(installedIDList, updateData.ProjectID)
+					}
+				}
+			}
+		}
+	}
+	return installedIDList
+}
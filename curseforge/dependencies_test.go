@@ -0,0 +1,101 @@
+package curseforge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+func TestFindDependencyCycle(t *testing.T) {
+	// 1 requires 2, which requires 3
+	requiredBy := map[int]int{2: 1, 3: 2}
+
+	tests := []struct {
+		name      string
+		fromID    int
+		depID     int
+		wantChain []int
+		wantFound bool
+	}{
+		{name: "cycle through chain", fromID: 3, depID: 1, wantChain: []int{1, 2, 3, 1}, wantFound: true},
+		{name: "direct cycle", fromID: 2, depID: 1, wantChain: []int{1, 2, 1}, wantFound: true},
+		{name: "self dependency", fromID: 1, depID: 1, wantChain: []int{1, 1}, wantFound: true},
+		{name: "dependency outside chain", fromID: 3, depID: 4},
+		{name: "dependency on a sibling", fromID: 2, depID: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, found := findDependencyCycle(requiredBy, tt.fromID, tt.depID)
+			if found != tt.wantFound {
+				t.Fatalf("findDependencyCycle() found = %v, want %v", found, tt.wantFound)
+			}
+			if !reflect.DeepEqual(chain, tt.wantChain) {
+				t.Errorf("findDependencyCycle() chain = %v, want %v", chain, tt.wantChain)
+			}
+		})
+	}
+}
+
+// cacheDependencyChain sets up the responses for a chain of mods from 1 to length, where each mod requires the next one
+func cacheDependencyChain(t *testing.T, length int) modInfo {
+	t.Helper()
+	// Mods are only resolved from the cache
+	newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request to %s", req.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	var first modInfo
+	for id := 1; id <= length; id++ {
+		file := modFileInfo{ID: id * 10, FileName: "mod-" + strconv.Itoa(id) + ".jar", FileType: fileTypeRelease, GameVersions: []string{"1.18.1", "Fabric"}}
+		if id < length {
+			if err := json.Unmarshal([]byte(`{"dependencies": [{"addonId": `+strconv.Itoa(id+1)+`, "type": 3}]}`), &file); err != nil {
+				t.Fatal(err)
+			}
+		}
+		info := modInfo{ID: id, Name: "Mod " + strconv.Itoa(id), LatestFiles: []modFileInfo{file}}
+		cacheSet("addon/"+strconv.Itoa(id), info)
+		cacheSet("addon/"+strconv.Itoa(id)+"/files", []modFileInfo{file})
+		if id == 1 {
+			first = info
+		}
+	}
+	return first
+}
+
+func TestResolveDependenciesDepth(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxDepth      int
+		wantRequired  int
+		wantTruncated bool
+	}{
+		// Deeper than any fixed limit on the number of levels that are resolved
+		{name: "unlimited", maxDepth: 0, wantRequired: 29},
+		{name: "direct dependencies", maxDepth: 1, wantRequired: 1, wantTruncated: true},
+		{name: "stops at depth", maxDepth: 3, wantRequired: 3, wantTruncated: true},
+		{name: "depth of the whole chain", maxDepth: 29, wantRequired: 29},
+		{name: "deeper than the chain", maxDepth: 40, wantRequired: 29},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := cacheDependencyChain(t, 30)
+			res, err := resolveDependencies(context.Background(), first, first.LatestFiles[0], core.Index{}, "1.18.1", modloaderTypeFabric, fileTypeRelease, tt.maxDepth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.required) != tt.wantRequired || res.truncated != tt.wantTruncated {
+				t.Errorf("resolved %d dependencies (truncated: %v), want %d (truncated: %v)", len(res.required), res.truncated, tt.wantRequired, tt.wantTruncated)
+			}
+			for i, dep := range res.required {
+				if dep.ID != i+2 {
+					t.Errorf("dependency %d is mod %d, want %d", i, dep.ID, i+2)
+				}
+			}
+		})
+	}
+}
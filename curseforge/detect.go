@@ -1,14 +1,19 @@
 package curseforge
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/aviddiviner/go-murmur"
 	"github.com/packwiz/packwiz/core"
+	"github.com/packwiz/packwiz/curseforge/murmur2"
 	"github.com/spf13/cobra"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 // TODO: make all of this less bad and hardcoded
@@ -19,133 +24,376 @@ var detectCmd = &cobra.Command{
 	Short: "Detect .jar files in the mods folder (experimental)",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Loading modpack...")
+		fmt.Fprintln(core.Output, "Loading modpack...")
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
-		// Walk files in the mods folder
-		var hashes []int
-		modPaths := make(map[int]string)
-		err = filepath.Walk("mods", func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
+		progressPath := filepath.Join(index.GetPackRoot(), detectProgressFile)
+		progress, err := loadDetectProgress(progressPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if len(progress.Files) > 0 {
+			fmt.Fprintln(core.Output, "Resuming previous detect run...")
+		}
+
+		_, err = hashDetectFiles(cmd.Context(), "mods", progress, progressPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		var pending []int
+		pendingSeen := make(map[int]bool)
+		for _, v := range progress.Files {
+			if !v.Submitted && !pendingSeen[v.Fingerprint] {
+				pending = append(pending, v.Fingerprint)
+				pendingSeen[v.Fingerprint] = true
 			}
-			if info.IsDir() {
-				return nil
+		}
+		fmt.Fprintf(core.Output, "Found %d files, submitting %d fingerprints...\n", len(progress.Files), len(pending))
+
+		for i := 0; i < len(pending); i += detectBatchSize {
+			end := i + detectBatchSize
+			if end > len(pending) {
+				end = len(pending)
 			}
-			if !strings.HasSuffix(path, ".jar") && !strings.HasSuffix(path, ".litemod") {
-				// TODO: make this less bad
-				return nil
+			res, err := getFingerprintInfoWithRetry(cmd.Context(), pending[i:end])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, "Progress has been saved; run detect again to resume")
+				os.Exit(1)
 			}
-			fmt.Println("Hashing " + path)
-			bytes, err := ioutil.ReadFile(path)
+			progress.recordResults(pending[i:end], res)
+			err = progress.save(progressPath)
 			if err != nil {
-				return err
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
 			}
-			hash := getByteArrayHash(bytes)
-			hashes = append(hashes, int(hash))
-			modPaths[int(hash)] = path
-			return nil
-		})
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
 		}
-		fmt.Printf("Found %d files, submitting...\n", len(hashes))
 
-		res, err := getFingerprintInfo(hashes)
-		if err != nil {
-			fmt.Println(err)
-			return
+		paths := make([]string, 0, len(progress.Files))
+		for path := range progress.Files {
+			paths = append(paths, path)
 		}
+		sort.Strings(paths)
 
-		fmt.Printf("Successfully matched %d files\n", len(res.ExactFingerprints))
-		if len(res.PartialMatches) > 0 {
-			fmt.Println("The following fingerprints were partial and I don't know what to do!!!")
-			for _, v := range res.PartialMatches {
-				fmt.Printf("%s (%d)", modPaths[v], v)
+		var matched, partial, unmatched []string
+		for _, path := range paths {
+			v := progress.Files[path]
+			if v.Match != nil {
+				matched = append(matched, path)
+			} else if v.Partial {
+				partial = append(partial, path)
+			} else {
+				unmatched = append(unmatched, path)
 			}
 		}
-		if len(res.UnmatchedFingerprints) > 0 {
-			fmt.Printf("Failed to match the following %d files:\n", len(res.UnmatchedFingerprints))
-			for _, v := range res.UnmatchedFingerprints {
-				fmt.Printf("%s (%d)\n", modPaths[v], v)
+
+		fmt.Fprintf(core.Output, "Successfully matched %d files\n", len(matched))
+		if len(partial) > 0 {
+			fmt.Fprintln(os.Stderr, "The following fingerprints were partial and I don't know what to do!!!")
+			for _, path := range partial {
+				fmt.Fprintf(os.Stderr, "%s (%d)\n", path, progress.Files[path].Fingerprint)
+			}
+		}
+		if len(unmatched) > 0 {
+			fmt.Fprintf(os.Stderr, "Failed to match the following %d files:\n", len(unmatched))
+			for _, path := range unmatched {
+				fmt.Fprintf(os.Stderr, "%s (%d)\n", path, progress.Files[path].Fingerprint)
 			}
 		}
-		fmt.Println("Installing...")
-		for _, v := range res.ExactMatches {
-			modInfoData, err := getModInfo(v.ID)
+		fmt.Fprintln(core.Output, "Installing...")
+		var unavailable []error
+		for _, path := range matched {
+			match := progress.Files[path].Match
+			modInfoData, err := getModInfo(cmd.Context(), match.ID)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
-			err = createModFile(modInfoData, v.File, &index, false)
+			err = createModFile(modInfoData, match.File, &index, false, "", match.File.getImportSide())
 			if err != nil {
-				fmt.Println(err)
+				if errors.Is(err, ErrDownloadUnavailable) {
+					// Leave the file in place, as it can't be downloaded automatically
+					unavailable = append(unavailable, err)
+					continue
+				}
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
-			path, ok := modPaths[v.File.Fingerprint]
-			if ok {
-				err = os.Remove(path)
-				if err != nil {
-					fmt.Println(err)
-					os.Exit(1)
-				}
+			err = os.Remove(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			delete(progress.Files, path)
+			err = progress.save(progressPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Fprintln(core.Output, "Installation done")
+		if len(unavailable) > 0 {
+			fmt.Fprintf(os.Stderr, "The following %d files were left in place, as they can't be downloaded automatically:\n", len(unavailable))
+			for _, err := range unavailable {
+				fmt.Fprintln(os.Stderr, err)
 			}
 		}
-		fmt.Println("Installation done")
 
 		err = index.Refresh()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = index.Write()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = pack.UpdateIndexHash()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = pack.Write()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		err = os.Remove(progressPath)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 	},
 }
 
-func init() {
-	curseforgeCmd.AddCommand(detectCmd)
+// detectProgressFile stores the fingerprints and results of a detect run, so that an interrupted run can be resumed
+// without rehashing or resubmitting files
+const detectProgressFile = ".packwiz-detect.json"
+
+// detectBatchSize is the number of fingerprints submitted to the API in each request
+const detectBatchSize = 100
+
+// fingerprintMaxAttempts is the number of times a fingerprint request is attempted before giving up
+const fingerprintMaxAttempts = 3
+
+// fingerprintRetryDelay is the time to wait before the first retry of a fingerprint request; it increases with each
+// attempt
+var fingerprintRetryDelay = 2 * time.Second
+
+type detectProgress struct {
+	Files map[string]detectFileProgress `json:"files"`
 }
 
-func getByteArrayHash(bytes []byte) uint64 {
-	return uint64(murmur.MurmurHash2(computeNormalizedArray(bytes), 1))
+type detectFileProgress struct {
+	Size        int64                  `json:"size"`
+	ModTime     time.Time              `json:"modTime"`
+	Fingerprint int                    `json:"fingerprint"`
+	Submitted   bool                   `json:"submitted"`
+	Partial     bool                   `json:"partial,omitempty"`
+	Match       *addonFingerprintMatch `json:"match,omitempty"`
 }
 
-func computeNormalizedArray(bytes []byte) []byte {
-	var newArray []byte
-	for _, b := range bytes {
-		if !isWhitespaceCharacter(b) {
-			newArray = append(newArray, b)
+func loadDetectProgress(path string) (detectProgress, error) {
+	progress := detectProgress{Files: make(map[string]detectFileProgress)}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return progress, nil
 		}
+		return progress, err
 	}
-	return newArray
+	err = json.Unmarshal(data, &progress)
+	if err != nil {
+		return progress, fmt.Errorf("failed to read detect progress (delete %s to start over): %w", path, err)
+	}
+	if progress.Files == nil {
+		progress.Files = make(map[string]detectFileProgress)
+	}
+	return progress, nil
+}
+
+func (p detectProgress) save(path string) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
 }
 
-func isWhitespaceCharacter(b byte) bool {
-	return b == 9 || b == 10 || b == 13 || b == 32
+// hashDetectFiles fingerprints the files in modsDir that haven't been hashed since they were last modified, saving the
+// progress periodically and before returning (so that an interrupted run can be resumed), and returns the paths of the
+// files that were hashed
+func hashDetectFiles(ctx context.Context, modsDir string, progress detectProgress, progressPath string) ([]string, error) {
+	var hashed []string
+	seen := make(map[string]bool)
+	hashedSinceSave := 0
+	err := filepath.Walk(modsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".jar") && !strings.HasSuffix(path, ".litemod") {
+			// TODO: make this less bad
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		seen[path] = true
+		if existing, ok := progress.Files[path]; ok && existing.Size == info.Size() && existing.ModTime.Equal(info.ModTime()) {
+			return nil
+		}
+		fmt.Fprintln(core.Output, "Hashing "+path)
+		hash, err := murmur2FingerprintFile(path)
+		if err != nil {
+			return err
+		}
+		progress.Files[path] = detectFileProgress{
+			Size:        info.Size(),
+			ModTime:     info.ModTime(),
+			Fingerprint: hash,
+		}
+		hashed = append(hashed, path)
+		hashedSinceSave++
+		if hashedSinceSave >= detectBatchSize {
+			hashedSinceSave = 0
+			return progress.save(progressPath)
+		}
+		return nil
+	})
+	if err == nil {
+		// Forget files that have been removed since the progress was saved
+		for path := range progress.Files {
+			if !seen[path] {
+				delete(progress.Files, path)
+			}
+		}
+	}
+	if saveErr := progress.save(progressPath); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return hashed, err
+}
+
+// recordResults marks every file with one of the given fingerprints as submitted, storing its match if there was one
+func (p detectProgress) recordResults(fingerprints []int, res addonFingerprintResponse) {
+	submitted := make(map[int]bool)
+	for _, v := range fingerprints {
+		submitted[v] = true
+	}
+	matches := make(map[int]addonFingerprintMatch)
+	for _, v := range res.ExactMatches {
+		matches[v.File.Fingerprint] = v
+	}
+	partials := make(map[int]bool)
+	for _, v := range res.PartialMatches {
+		partials[v] = true
+	}
+
+	for path, v := range p.Files {
+		if !submitted[v.Fingerprint] {
+			continue
+		}
+		v.Submitted = true
+		if match, ok := matches[v.Fingerprint]; ok {
+			v.Match = &match
+		}
+		v.Partial = partials[v.Fingerprint]
+		p.Files[path] = v
+	}
+}
+
+// getFingerprintInfoWithRetry calls getFingerprintInfo, retrying with an increasing delay if the request fails or
+// CurseForge's fingerprint cache is not built yet (returning ErrFingerprintCacheNotBuilt if it is never built)
+func getFingerprintInfoWithRetry(ctx context.Context, hashes []int) (addonFingerprintResponse, error) {
+	var err error
+	for attempt := 1; attempt <= fingerprintMaxAttempts; attempt++ {
+		var res addonFingerprintResponse
+		res, err = getFingerprintInfo(ctx, hashes)
+		if err == nil {
+			if res.IsCacheBuilt {
+				return res, nil
+			}
+			err = ErrFingerprintCacheNotBuilt
+		}
+		if ctx.Err() != nil || attempt == fingerprintMaxAttempts {
+			break
+		}
+		delay := time.Duration(attempt) * fingerprintRetryDelay
+		if errors.Is(err, ErrFingerprintCacheNotBuilt) {
+			fmt.Fprintf(core.Output, "The CurseForge fingerprint cache is not built yet, retrying in %v...\n", delay)
+		} else {
+			fmt.Fprintf(os.Stderr, "Failed to submit fingerprints (%v), retrying in %v...\n", err, delay)
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return addonFingerprintResponse{}, ctx.Err()
+		}
+	}
+	return addonFingerprintResponse{}, err
+}
+
+func init() {
+	curseforgeCmd.AddCommand(detectCmd)
+	core.FileIdentifiers["curseforge"] = cfIdentifier{}
+}
+
+type cfIdentifier struct{}
+
+func (cfIdentifier) IdentifyFile(ctx context.Context, data []byte) (core.FileIdentification, bool, error) {
+	hash := murmur2Fingerprint(data)
+	res, err := getFingerprintInfo(ctx, []int{hash})
+	if err != nil {
+		return core.FileIdentification{}, false, err
+	}
+	if len(res.ExactMatches) == 0 {
+		return core.FileIdentification{}, false, nil
+	}
+
+	match := res.ExactMatches[0]
+	modInfoData, err := getModInfo(ctx, match.ID)
+	if err != nil {
+		return core.FileIdentification{}, false, err
+	}
+	return core.FileIdentification{
+		ProjectName: modInfoData.Name,
+		URL:         modInfoData.WebsiteURL,
+		Version:     match.File.FriendlyName,
+	}, true, nil
+}
+
+// murmur2Fingerprint computes the fingerprint CurseForge uses to identify files: murmur2 (with a seed of 1) over the
+// file contents, with whitespace bytes (tab, LF, CR and space) removed
+func murmur2Fingerprint(data []byte) int {
+	h := murmur2.New()
+	_, _ = h.Write(data)
+	return int(h.Sum32())
+}
+
+// murmur2FingerprintFile computes the CurseForge fingerprint of the file at the given path
+func murmur2FingerprintFile(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return murmur2Fingerprint(data), nil
 }
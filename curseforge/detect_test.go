@@ -0,0 +1,255 @@
+package curseforge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+func TestMurmur2Fingerprint(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// The fingerprint is a 32-bit unsigned value, which doesn't fit in an int on 32-bit platforms
+		want uint32
+	}{
+		{name: "empty", data: "", want: 1540447798},
+		{name: "no whitespace", data: "helloworld", want: 2824650221},
+		{name: "whitespace removed", data: "hello world\n", want: 2824650221},
+		{name: "tabs and CRLF removed", data: "packwiz\tmod\r\n.jar", want: 704458626},
+		{name: "partial block", data: "abc", want: 1621425345},
+		{name: "jar manifest", data: "Manifest-Version: 1.0\r\n\r\n", want: 1803207771},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uint32(murmur2Fingerprint([]byte(tt.data))); got != tt.want {
+				t.Errorf("murmur2Fingerprint() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMurmur2FingerprintFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mod.jar")
+	if err := ioutil.WriteFile(path, []byte("hello world\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := murmur2FingerprintFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint32(2824650221); uint32(got) != want {
+		t.Errorf("murmur2FingerprintFile() = %d, want %d", uint32(got), want)
+	}
+
+	if _, err := murmur2FingerprintFile(filepath.Join(t.TempDir(), "missing.jar")); err == nil {
+		t.Error("murmur2FingerprintFile() returned no error for a missing file")
+	}
+}
+
+func TestMurmur2FingerprintJar(t *testing.T) {
+	// example-mod.jar is a small Fabric mod jar, with whitespace bytes in its compressed data
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "example-mod.jar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = uint32(1241222313)
+	if got := uint32(murmur2Fingerprint(data)); got != want {
+		t.Errorf("murmur2Fingerprint() = %d, want %d", got, want)
+	}
+
+	var stripped []byte
+	for _, b := range data {
+		if b != 9 && b != 10 && b != 13 && b != 32 {
+			stripped = append(stripped, b)
+		}
+	}
+	if len(stripped) == len(data) {
+		t.Fatal("example-mod.jar doesn't contain any whitespace bytes")
+	}
+	if got := uint32(murmur2Fingerprint(stripped)); got != want {
+		t.Errorf("murmur2Fingerprint() without whitespace = %d, want %d", got, want)
+	}
+}
+
+func TestIdentifyFile(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "example-mod.jar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint := murmur2Fingerprint(data)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fingerprint", func(w http.ResponseWriter, req *http.Request) {
+		var hashes []int
+		if err := json.NewDecoder(req.Body).Decode(&hashes); err != nil {
+			t.Error(err)
+		}
+		res := addonFingerprintResponse{IsCacheBuilt: true}
+		for _, hash := range hashes {
+			if hash == fingerprint {
+				res.ExactMatches = append(res.ExactMatches, addonFingerprintMatch{
+					ID:   1234,
+					File: modFileInfo{ID: 5678, FileName: "example-mod-1.0.0.jar", FriendlyName: "Example Mod 1.0.0"},
+				})
+				res.ExactFingerprints = append(res.ExactFingerprints, hash)
+			} else {
+				res.UnmatchedFingerprints = append(res.UnmatchedFingerprints, hash)
+			}
+		}
+		writeTestJSON(t, w, res)
+	})
+	mux.HandleFunc("/addon/1234", func(w http.ResponseWriter, req *http.Request) {
+		writeTestJSON(t, w, modInfo{ID: 1234, Name: "Example Mod", WebsiteURL: "https://www.curseforge.com/minecraft/mc-mods/example-mod"})
+	})
+	newTestAPI(t, mux)
+
+	ident, ok, err := cfIdentifier{}.IdentifyFile(context.Background(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("IdentifyFile() didn't find the file")
+	}
+	want := core.FileIdentification{
+		ProjectName: "Example Mod",
+		URL:         "https://www.curseforge.com/minecraft/mc-mods/example-mod",
+		Version:     "Example Mod 1.0.0",
+	}
+	if ident != want {
+		t.Errorf("IdentifyFile() = %+v, want %+v", ident, want)
+	}
+
+	if _, ok, err := (cfIdentifier{}).IdentifyFile(context.Background(), []byte("not a mod")); err != nil || ok {
+		t.Errorf("IdentifyFile() for an unknown file = %v, %v, want no match", ok, err)
+	}
+}
+
+// interruptAfterContext is a context that is cancelled after its Err method has been called a given number of times,
+// to interrupt a loop at a known point
+type interruptAfterContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *interruptAfterContext) Err() error {
+	if c.remaining <= 0 {
+		return context.Canceled
+	}
+	c.remaining--
+	return nil
+}
+
+func TestHashDetectFilesResume(t *testing.T) {
+	dir := t.TempDir()
+	modsDir := filepath.Join(dir, "mods")
+	if err := os.MkdirAll(modsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.jar", "b.jar", "c.jar", "readme.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(modsDir, name), []byte("contents of "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	progressPath := filepath.Join(dir, detectProgressFile)
+	a, b, c := filepath.Join(modsDir, "a.jar"), filepath.Join(modsDir, "b.jar"), filepath.Join(modsDir, "c.jar")
+
+	progress, err := loadDetectProgress(progressPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashed, err := hashDetectFiles(&interruptAfterContext{Context: context.Background(), remaining: 2}, modsDir, progress, progressPath)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("hashDetectFiles() error = %v, want it to be interrupted", err)
+	}
+	if !reflect.DeepEqual(hashed, []string{a, b}) {
+		t.Errorf("interrupted hashDetectFiles() hashed %v, want %v", hashed, []string{a, b})
+	}
+
+	// Resume from the saved progress
+	progress, err = loadDetectProgress(progressPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress.Files) != 2 {
+		t.Fatalf("saved progress has %d files, want 2", len(progress.Files))
+	}
+	hashed, err = hashDetectFiles(context.Background(), modsDir, progress, progressPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hashed, []string{c}) {
+		t.Errorf("resumed hashDetectFiles() hashed %v, want %v", hashed, []string{c})
+	}
+	if got := progress.Files[c].Fingerprint; got != murmur2Fingerprint([]byte("contents of c.jar")) {
+		t.Errorf("c.jar has a fingerprint of %d, want the fingerprint of its contents", got)
+	}
+
+	// Files that were changed or removed since the last run are rehashed or forgotten
+	if err := ioutil.WriteFile(a, []byte("new contents of a.jar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(b); err != nil {
+		t.Fatal(err)
+	}
+	hashed, err = hashDetectFiles(context.Background(), modsDir, progress, progressPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hashed, []string{a}) {
+		t.Errorf("hashDetectFiles() after changes hashed %v, want %v", hashed, []string{a})
+	}
+	if _, ok := progress.Files[b]; ok {
+		t.Error("removed file b.jar is still in the progress")
+	}
+}
+
+func TestGetFingerprintInfoWithRetry(t *testing.T) {
+	oldDelay := fingerprintRetryDelay
+	fingerprintRetryDelay = time.Millisecond
+	defer func() { fingerprintRetryDelay = oldDelay }()
+
+	tests := []struct {
+		name         string
+		builtAfter   int
+		wantErr      error
+		wantRequests int
+	}{
+		{name: "built", builtAfter: 1, wantRequests: 1},
+		{name: "not built then built", builtAfter: 2, wantRequests: 2},
+		{name: "never built", builtAfter: fingerprintMaxAttempts + 1, wantErr: ErrFingerprintCacheNotBuilt, wantRequests: fingerprintMaxAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				res := addonFingerprintResponse{IsCacheBuilt: int(atomic.AddInt32(&requests, 1)) >= tt.builtAfter}
+				if res.IsCacheBuilt {
+					res.ExactMatches = []addonFingerprintMatch{{ID: 1234, File: modFileInfo{ID: 5678, Fingerprint: 42}}}
+				}
+				writeTestJSON(t, w, res)
+			}))
+
+			res, err := getFingerprintInfoWithRetry(context.Background(), []int{42})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("getFingerprintInfoWithRetry() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := int(atomic.LoadInt32(&requests)); got != tt.wantRequests {
+				t.Errorf("getFingerprintInfoWithRetry() sent %d requests, want %d", got, tt.wantRequests)
+			}
+			if err == nil && (len(res.ExactMatches) != 1 || res.ExactMatches[0].ID != 1234) {
+				t.Errorf("getFingerprintInfoWithRetry() = %+v, want the match from the built cache", res)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package curseforge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrModNotFound is returned when the requested mod or file doesn't exist on CurseForge
+var ErrModNotFound = errors.New("mod not found")
+
+// ErrUnexpectedResponse is returned when CurseForge returns data that doesn't match what was requested
+var ErrUnexpectedResponse = errors.New("unexpected response from CurseForge")
+
+// ErrFingerprintCacheNotBuilt is returned when CurseForge's fingerprint cache is still being built, so fingerprints
+// can't be looked up yet
+var ErrFingerprintCacheNotBuilt = errors.New("the CurseForge fingerprint cache is not built yet; try again later")
+
+// ErrDownloadUnavailable is returned when a file can't be downloaded through the API, as the author has disabled
+// third-party downloads
+var ErrDownloadUnavailable = errors.New("file can't be downloaded through the CurseForge API")
+
+// CurseForgeAPIError is returned when a CurseForge API request fails, with the details given in the response
+type CurseForgeAPIError struct {
+	StatusCode int
+	Message    string `json:"message"`
+	Exception  string `json:"exception"`
+}
+
+func (e *CurseForgeAPIError) Error() string {
+	msg := fmt.Sprintf("CurseForge API request failed (status %d)", e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Exception != "" {
+		msg += " (" + e.Exception + ")"
+	}
+	return msg
+}
+
+// checkResponse returns ErrModNotFound for 404 responses, and a CurseForgeAPIError for any other unsuccessful response
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrModNotFound
+	}
+
+	apiErr := &CurseForgeAPIError{StatusCode: resp.StatusCode}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err == nil {
+		// The body isn't always JSON; just leave the message empty if it can't be parsed
+		_ = json.Unmarshal(body, apiErr)
+	}
+	apiErr.StatusCode = resp.StatusCode
+	return apiErr
+}
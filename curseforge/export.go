@@ -1,14 +1,17 @@
 package curseforge
 
 import (
-	"archive/zip"
 	"bufio"
 	"fmt"
 	"github.com/packwiz/packwiz/curseforge/packinterop"
 	"github.com/spf13/viper"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/packwiz/packwiz/core"
 	"github.com/spf13/cobra"
@@ -22,41 +25,45 @@ var exportCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		side := viper.GetString("curseforge.export.side")
 		if len(side) == 0 || (side != core.UniversalSide && side != core.ServerSide && side != core.ClientSide) {
-			fmt.Println("Invalid side!")
+			fmt.Fprintln(os.Stderr, "Invalid side!")
+			os.Exit(1)
+		}
+		if viper.GetBool("curseforge.export.server-scripts") && side != core.ServerSide {
+			fmt.Fprintln(os.Stderr, "Server start scripts can only be exported with server packs (--side server)")
 			os.Exit(1)
 		}
 
-		fmt.Println("Loading modpack...")
+		fmt.Fprintln(core.Output, "Loading modpack...")
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		// Do a refresh to ensure files are up to date
 		err = index.Refresh()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = index.Write()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = pack.UpdateIndexHash()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = pack.Write()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 
 		// TODO: should index just expose indexPath itself, through a function?
@@ -73,17 +80,55 @@ var exportCmd = &cobra.Command{
 			}
 		}
 		mods = mods[:i]
+		if targetLoader := viper.GetString("curseforge.export.target-loader"); targetLoader != "" {
+			pack, err = pack.WithTargetLoader(targetLoader, viper.GetString("curseforge.export.target-loader-version"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(core.Output, "Selecting %s files...\n", core.ComponentToFriendlyName(targetLoader))
+			var skipped []string
+			mods, skipped, err = core.SelectModsForPack(cmd.Context(), mods, pack)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			for _, v := range skipped {
+				fmt.Fprintf(os.Stderr, "Warning: a supported update system for \"%s\" cannot be found, so its file is exported unchanged\n", v)
+			}
+		}
+		if viper.GetBool("curseforge.export.reproducible") {
+			core.SortModsForExport(mods)
+		}
 
 		var exportData cfExportData
 		exportDataUnparsed, ok := pack.Export["curseforge"]
 		if ok {
 			exportData, err = parseExportData(exportDataUnparsed)
 			if err != nil {
-				fmt.Printf("Failed to parse export metadata: %s\n", err.Error())
+				fmt.Fprintf(os.Stderr, "Failed to parse export metadata: %s\n", err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if viper.GetBool("curseforge.export.embed-installer") {
+			if viper.GetString("curseforge.export.pack-url") == "" {
+				fmt.Fprintln(os.Stderr, "The URL of pack.toml must be given with --pack-url to embed packwiz-installer")
+				os.Exit(1)
+			}
+			err = core.ValidatePackURL(viper.GetString("curseforge.export.pack-url"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 		}
 
+		overridesDir := path.Clean(viper.GetString("curseforge.export.overrides-dir"))
+		if overridesDir == "." || path.IsAbs(overridesDir) || strings.HasPrefix(overridesDir, "../") {
+			fmt.Fprintln(os.Stderr, "Invalid overrides directory!")
+			os.Exit(1)
+		}
+
 		fileName := viper.GetString("curseforge.export.output")
 		if fileName == "" {
 			fileName = pack.GetPackName() + ".zip"
@@ -91,15 +136,15 @@ var exportCmd = &cobra.Command{
 
 		expFile, err := os.Create(fileName)
 		if err != nil {
-			fmt.Printf("Failed to create zip: %s\n", err.Error())
+			fmt.Fprintf(os.Stderr, "Failed to create zip: %s\n", err.Error())
 			os.Exit(1)
 		}
-		exp := zip.NewWriter(expFile)
+		exp := core.NewExportZip(expFile)
 
 		// Add an overrides folder even if there are no files to go in it
-		_, err = exp.Create("overrides/")
+		_, err = exp.Create(overridesDir + "/")
 		if err != nil {
-			fmt.Printf("Failed to add overrides folder: %s\n", err.Error())
+			fmt.Fprintf(os.Stderr, "Failed to add overrides folder: %s\n", err.Error())
 			os.Exit(1)
 		}
 
@@ -114,26 +159,34 @@ var exportCmd = &cobra.Command{
 					ProjectID:        p.ProjectID,
 					FileID:           p.FileID,
 					OptionalDisabled: mod.Option != nil && mod.Option.Optional && !mod.Option.Default,
+					FileName:         mod.FileName,
+					HashFormat:       mod.Download.HashFormat,
+					Hash:             mod.Download.Hash,
 				})
+				if destPaths := index.GetModDestPaths(mod); len(destPaths) > 1 || destPaths[0] != mod.GetDestFilePath() {
+					fmt.Fprintf(os.Stderr, "Warning: aliases for %s can't be exported, as CurseForge packs always install mods to the mods folder\n", mod.Name)
+				}
 			} else {
 				// If the mod doesn't have the metadata, save it into the zip
-				path, err := filepath.Rel(filepath.Dir(indexPath), mod.GetDestFilePath())
-				if err != nil {
-					fmt.Printf("Error resolving mod file: %s\n", err.Error())
-					// TODO: exit(1)?
-					continue
-				}
-				modFile, err := exp.Create(filepath.ToSlash(filepath.Join("overrides", path)))
-				if err != nil {
-					fmt.Printf("Error creating mod file %s: %s\n", path, err.Error())
-					// TODO: exit(1)?
-					continue
-				}
-				err = mod.DownloadFile(modFile)
-				if err != nil {
-					fmt.Printf("Error downloading mod file %s: %s\n", path, err.Error())
-					// TODO: exit(1)?
-					continue
+				for _, destPath := range index.GetModDestPaths(mod) {
+					path, err := filepath.Rel(filepath.Dir(indexPath), destPath)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error resolving mod file: %s\n", err.Error())
+						// TODO: exit(1)?
+						continue
+					}
+					modFile, err := exp.Create(overridesDir + "/" + filepath.ToSlash(path))
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error creating mod file %s: %s\n", path, err.Error())
+						// TODO: exit(1)?
+						continue
+					}
+					err = mod.DownloadFile(modFile)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error downloading mod file %s: %s\n", path, err.Error())
+						// TODO: exit(1)?
+						continue
+					}
 				}
 			}
 		}
@@ -142,23 +195,36 @@ var exportCmd = &cobra.Command{
 		if err != nil {
 			_ = exp.Close()
 			_ = expFile.Close()
-			fmt.Println("Error creating manifest: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error creating manifest: "+err.Error())
 			os.Exit(1)
 		}
 
-		err = packinterop.WriteManifestFromPack(pack, cfFileRefs, exportData.ProjectID, manifestFile)
+		err = packinterop.WriteManifestFromPack(pack, cfFileRefs, exportData.ProjectID, overridesDir, manifestFile)
 		if err != nil {
 			_ = exp.Close()
 			_ = expFile.Close()
-			fmt.Println("Error creating manifest: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error creating manifest: "+err.Error())
 			os.Exit(1)
 		}
 
+		if viper.GetBool("curseforge.export.include-hashes") {
+			hashesFile, err := exp.Create(packinterop.ManifestHashesFile)
+			if err == nil {
+				err = packinterop.WriteManifestHashes(cfFileRefs, hashesFile)
+			}
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintln(os.Stderr, "Error creating manifest hashes: "+err.Error())
+				os.Exit(1)
+			}
+		}
+
 		err = createModlist(exp, mods)
 		if err != nil {
 			_ = exp.Close()
 			_ = expFile.Close()
-			fmt.Println("Error creating mod list: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error creating mod list: "+err.Error())
 			os.Exit(1)
 		}
 
@@ -166,21 +232,21 @@ var exportCmd = &cobra.Command{
 		for _, v := range index.Files {
 			if !v.MetaFile {
 				// Save all non-metadata files into the zip
-				path, err := filepath.Rel(filepath.Dir(indexPath), index.GetFilePath(v))
+				path, err := filepath.Rel(filepath.Dir(indexPath), index.GetFileDestPath(v))
 				if err != nil {
-					fmt.Printf("Error resolving file: %s\n", err.Error())
+					fmt.Fprintf(os.Stderr, "Error resolving file: %s\n", err.Error())
 					// TODO: exit(1)?
 					continue
 				}
-				file, err := exp.Create(filepath.ToSlash(filepath.Join("overrides", path)))
+				file, err := exp.Create(overridesDir + "/" + filepath.ToSlash(path))
 				if err != nil {
-					fmt.Printf("Error creating file: %s\n", err.Error())
+					fmt.Fprintf(os.Stderr, "Error creating file: %s\n", err.Error())
 					// TODO: exit(1)?
 					continue
 				}
 				err = index.SaveFile(v, file)
 				if err != nil {
-					fmt.Printf("Error copying file: %s\n", err.Error())
+					fmt.Fprintf(os.Stderr, "Error copying file: %s\n", err.Error())
 					// TODO: exit(1)?
 					continue
 				}
@@ -188,22 +254,70 @@ var exportCmd = &cobra.Command{
 			}
 		}
 
+		if viper.GetBool("curseforge.export.server-scripts") {
+			err = writeServerScripts(exp, overridesDir, pack)
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintln(os.Stderr, "Error creating server start scripts: "+err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if viper.GetBool("curseforge.export.embed-installer") {
+			fmt.Fprintln(core.Output, "Downloading packwiz-installer...")
+			err = core.WriteEmbeddedInstaller(exp, overridesDir, viper.GetString("curseforge.export.installer-url"), viper.GetString("curseforge.export.pack-url"), side)
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintln(os.Stderr, "Error embedding packwiz-installer: "+err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if viper.GetBool("curseforge.export.bom") {
+			bomFile, err := exp.Create(core.BOMFile)
+			if err == nil {
+				err = core.WriteBOM(bomFile, pack, index, mods)
+			}
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintln(os.Stderr, "Error creating bill of materials: "+err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if viper.GetBool("curseforge.export.license-summary") {
+			fmt.Fprintln(core.Output, "Retrieving licenses...")
+			licenseFile, err := exp.Create(core.LicenseSummaryFile)
+			if err == nil {
+				err = core.WriteLicenseSummary(cmd.Context(), licenseFile, pack.Name, mods)
+			}
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintln(os.Stderr, "Error creating license summary: "+err.Error())
+				os.Exit(1)
+			}
+		}
+
 		err = exp.Close()
 		if err != nil {
-			fmt.Println("Error writing export file: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error writing export file: "+err.Error())
 			os.Exit(1)
 		}
 		err = expFile.Close()
 		if err != nil {
-			fmt.Println("Error writing export file: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error writing export file: "+err.Error())
 			os.Exit(1)
 		}
 
-		fmt.Println("Modpack exported to " + fileName)
+		fmt.Fprintln(core.Output, "Modpack exported to "+fileName)
 	},
 }
 
-func createModlist(zw *zip.Writer, mods []core.Mod) error {
+func createModlist(zw *core.ExportZip, mods []core.Mod) error {
 	modlistFile, err := zw.Create("modlist.html")
 	if err != nil {
 		return err
@@ -239,15 +353,45 @@ func createModlist(zw *zip.Writer, mods []core.Mod) error {
 	return w.Flush()
 }
 
+// writeServerScripts adds scripts to start the server to the overrides folder
+func writeServerScripts(zw *core.ExportZip, overridesDir string, pack core.Pack) error {
+	scripts, err := pack.GetServerStartScripts()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(scripts))
+	for k := range scripts {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var scriptFile io.Writer
+		if strings.HasSuffix(name, ".sh") {
+			scriptFile, err = zw.CreateExecutable(overridesDir + "/" + name)
+		} else {
+			scriptFile, err = zw.Create(overridesDir + "/" + name)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(scriptFile, scripts[name])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func loadMods(index core.Index) []core.Mod {
 	modPaths := index.GetAllMods()
 	mods := make([]core.Mod, len(modPaths))
 	i := 0
-	fmt.Println("Reading mod files...")
+	fmt.Fprintln(core.Output, "Reading mod files...")
 	for _, v := range modPaths {
 		modData, err := core.LoadMod(v)
 		if err != nil {
-			fmt.Printf("Error reading mod file %s: %s\n", v, err.Error())
+			fmt.Fprintf(os.Stderr, "Error reading mod file %s: %s\n", v, err.Error())
 			// TODO: exit(1)?
 			continue
 		}
@@ -265,4 +409,26 @@ func init() {
 	_ = viper.BindPFlag("curseforge.export.side", exportCmd.Flags().Lookup("side"))
 	exportCmd.Flags().StringP("output", "o", "", "The file to export the modpack to")
 	_ = viper.BindPFlag("curseforge.export.output", exportCmd.Flags().Lookup("output"))
+	exportCmd.Flags().String("overrides-dir", "overrides", "The name of the folder in the exported zip to store override files in")
+	_ = viper.BindPFlag("curseforge.export.overrides-dir", exportCmd.Flags().Lookup("overrides-dir"))
+	exportCmd.Flags().String("target-loader", "", "Export a variant of the pack for this loader (e.g. fabric or forge), selecting a compatible file for each mod")
+	_ = viper.BindPFlag("curseforge.export.target-loader", exportCmd.Flags().Lookup("target-loader"))
+	exportCmd.Flags().String("target-loader-version", "", "The version of the target loader to use (defaults to the pack's version of the loader, or the latest version)")
+	_ = viper.BindPFlag("curseforge.export.target-loader-version", exportCmd.Flags().Lookup("target-loader-version"))
+	exportCmd.Flags().Bool("reproducible", false, "Sort the exported files, so that exporting the same pack always produces an identical zip")
+	_ = viper.BindPFlag("curseforge.export.reproducible", exportCmd.Flags().Lookup("reproducible"))
+	exportCmd.Flags().Bool("server-scripts", false, "Include scripts to start the server (start.sh and start.bat) for the pack's loader; requires --side server")
+	_ = viper.BindPFlag("curseforge.export.server-scripts", exportCmd.Flags().Lookup("server-scripts"))
+	exportCmd.Flags().Bool("include-hashes", false, "Include the hash of each file in the manifest in a sidecar file ("+packinterop.ManifestHashesFile+")")
+	_ = viper.BindPFlag("curseforge.export.include-hashes", exportCmd.Flags().Lookup("include-hashes"))
+	exportCmd.Flags().Bool("embed-installer", false, "Include the packwiz-installer bootstrap jar, a config file and scripts to run it, so that the pack can update itself from --pack-url")
+	_ = viper.BindPFlag("curseforge.export.embed-installer", exportCmd.Flags().Lookup("embed-installer"))
+	exportCmd.Flags().String("installer-url", core.DefaultInstallerURL, "The URL to download the packwiz-installer bootstrap jar from, when using --embed-installer")
+	_ = viper.BindPFlag("curseforge.export.installer-url", exportCmd.Flags().Lookup("installer-url"))
+	exportCmd.Flags().String("pack-url", "", "The URL of the pack's pack.toml, for packwiz-installer to update the pack from when using --embed-installer")
+	_ = viper.BindPFlag("curseforge.export.pack-url", exportCmd.Flags().Lookup("pack-url"))
+	exportCmd.Flags().Bool("bom", false, "Include a bill of materials ("+core.BOMFile+") listing the source, version, hash and size of every file in the pack")
+	_ = viper.BindPFlag("curseforge.export.bom", exportCmd.Flags().Lookup("bom"))
+	exportCmd.Flags().Bool("license-summary", false, "Include a summary of the licenses of all mods, in SPDX format")
+	_ = viper.BindPFlag("curseforge.export.license-summary", exportCmd.Flags().Lookup("license-summary"))
 }
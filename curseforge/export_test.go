@@ -0,0 +1,73 @@
+package curseforge
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportOverridesDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"pack.toml": "name = \"Test Pack\"\nversion = \"1.0.0\"\npack-format = \"packwiz:1.0.0\"\n\n[index]\nfile = \"index.toml\"\n" +
+			"hash-format = \"sha256\"\n\n[versions]\nminecraft = \"1.18.2\"\nfabric = \"0.13.3\"\n",
+		"index.toml":           "hash-format = \"sha256\"\n",
+		"config/settings.json": "{}",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(t.TempDir(), "export.zip")
+	root := exportCmd.Root()
+	root.SetArgs([]string{"curseforge", "export", "--quiet", "--pack-file", filepath.Join(dir, "pack.toml"), "--output", output,
+		"--overrides-dir", "client-overrides"})
+	defer root.SetArgs(nil)
+	if err := root.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	found := false
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "overrides/") {
+			t.Errorf("%s was exported to the default overrides folder", f.Name)
+		}
+		if f.Name == "client-overrides/config/settings.json" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("config/settings.json wasn't exported to the client-overrides folder")
+	}
+
+	f, err := zr.Open("manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var manifest struct {
+		Overrides string `json:"overrides"`
+	}
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Overrides != "client-overrides" {
+		t.Errorf("manifest overrides folder = %q, want %q", manifest.Overrides, "client-overrides")
+	}
+}
@@ -0,0 +1,205 @@
+package curseforge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var errNoFileAvailable = errors.New("mod not available for the configured Minecraft version(s) (use the acceptable-game-versions option to accept more) or loader")
+
+// findBestFile selects the newest file in LatestFiles for the given Minecraft version and loader, with a release type at
+// or below the given release channel (e.g. fileTypeBeta accepts release and beta files, but not alpha files)
+func (i modInfo) findBestFile(mcVersion string, loader int, channel int) (modFileInfo, error) {
+	// Files for the pack's loader are preferred over files for a fallback loader (e.g. Fabric files on Quilt)
+	if _, ok := getFallbackLoaderType(loader); ok {
+		if fileInfoData, ok := i.findBestFileForLoader(mcVersion, loader, channel, false); ok {
+			return fileInfoData, nil
+		}
+	}
+	if fileInfoData, ok := i.findBestFileForLoader(mcVersion, loader, channel, true); ok {
+		return fileInfoData, nil
+	}
+	return modFileInfo{}, errNoFileAvailable
+}
+
+func (i modInfo) findBestFileForLoader(mcVersion string, loader int, channel int, allowFallback bool) (modFileInfo, bool) {
+	// When building a server pack, a client-only file is only used if there is no server-compatible one
+	preferServer := viper.GetBool("curseforge.install.prefer-server")
+
+	cutoff := getMinFileAgeCutoff()
+
+	var bestFile, bestServerFile modFileInfo
+	found := false
+	serverFound := false
+	for _, v := range i.LatestFiles {
+		if v.FileType > channel || !matchGameVersions(mcVersion, v.getMCVersions()) || !matchLoaderTypeFileInfo(loader, v, allowFallback) {
+			continue
+		}
+		if !cutoff.IsZero() && v.Date.After(cutoff) {
+			continue
+		}
+		// Choose "newest" version by largest ID
+		if !found || v.ID > bestFile.ID {
+			bestFile = v
+			found = true
+		}
+		if !isClientOnlyFile(v) && (!serverFound || v.ID > bestServerFile.ID) {
+			bestServerFile = v
+			serverFound = true
+		}
+	}
+	if preferServer && serverFound {
+		return bestServerFile, true
+	}
+	return bestFile, found
+}
+
+// findMatchingFiles returns every file in LatestFiles for the given Minecraft version, loader (or fallback loader) and
+// release channel, newest first
+func (i modInfo) findMatchingFiles(mcVersion string, loader int, channel int) []modFileInfo {
+	cutoff := getMinFileAgeCutoff()
+
+	var files []modFileInfo
+	for _, v := range i.LatestFiles {
+		if !cutoff.IsZero() && v.Date.After(cutoff) {
+			continue
+		}
+		if v.FileType <= channel && matchGameVersions(mcVersion, v.getMCVersions()) && matchLoaderTypeFileInfo(loader, v, true) {
+			files = append(files, v)
+		}
+	}
+	sort.Slice(files, func(a, b int) bool {
+		return files[a].ID > files[b].ID
+	})
+	return files
+}
+
+// latestFile is the result of findLatestFile; the file info is only included if it was in the mod info response
+type latestFile struct {
+	fileID      int
+	fileName    string
+	hasFileInfo bool
+	fileInfo    modFileInfo
+	// fallback is the best file from LatestFiles, if a newer file was found in GameVersionLatestFiles
+	hasFallback bool
+	fallback    modFileInfo
+}
+
+// findLatestFile finds the newest file for the given Minecraft version, loader and release channel, from both
+// LatestFiles and GameVersionLatestFiles
+func findLatestFile(modInfoData modInfo, mcVersion string, packLoaderType int, channel int) (latestFile, error) {
+	// For snapshots, curseforge doesn't put them in GameVersionLatestFiles
+	bestFile, err := modInfoData.findBestFile(mcVersion, packLoaderType, channel)
+	found := err == nil
+
+	newerFileID := bestFile.ID
+	var newerFileName string
+	// GameVersionLatestFiles doesn't include release dates, so it can't be used if there is a minimum file age
+	gameVersionLatestFiles := modInfoData.GameVersionLatestFiles
+	if !getMinFileAgeCutoff().IsZero() {
+		gameVersionLatestFiles = nil
+	}
+	for _, v := range gameVersionLatestFiles {
+		// Only use files for a fallback loader if there aren't any files for the pack's loader in LatestFiles
+		// Choose "newest" version by largest ID
+		if v.FileType <= channel && matchGameVersion(mcVersion, v.GameVersion) && v.ID > newerFileID && matchLoaderType(packLoaderType, v.Modloader, !found) {
+			newerFileID = v.ID
+			newerFileName = v.Name
+		}
+	}
+
+	if newerFileID != bestFile.ID {
+		// The API also provides some files inline, because that's efficient!
+		for _, v := range modInfoData.LatestFiles {
+			if v.ID == newerFileID {
+				return latestFile{
+					fileID:      v.ID,
+					fileName:    v.FileName,
+					hasFileInfo: true,
+					fileInfo:    v,
+				}, nil
+			}
+		}
+		return latestFile{
+			fileID:      newerFileID,
+			fileName:    newerFileName,
+			hasFallback: found,
+			fallback:    bestFile,
+		}, nil
+	}
+	if !found {
+		return latestFile{}, err
+	}
+	return latestFile{
+		fileID:      bestFile.ID,
+		fileName:    bestFile.FileName,
+		hasFileInfo: true,
+		fileInfo:    bestFile,
+	}, nil
+}
+
+// getMinFileAgeCutoff returns the release time that files must be older than, from the curseforge.min-file-age option
+// (in hours), or the zero time if there is no minimum age
+func getMinFileAgeCutoff() time.Time {
+	hours := viper.GetInt("curseforge.min-file-age")
+	if hours <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-time.Duration(hours) * time.Hour)
+}
+
+// findLatestFileWithAllFiles finds the newest file in the same way as findLatestFile, with every file of the mod from
+// the files endpoint merged with LatestFiles (without duplicates), so that the best file is chosen from LatestFiles,
+// GameVersionLatestFiles and the files endpoint together. Installing and updating both use this, so that they select
+// the same file.
+func findLatestFileWithAllFiles(ctx context.Context, modInfoData modInfo, mcVersion string, packLoaderType int, channel int) (latestFile, error) {
+	files, err := getModFiles(ctx, modInfoData.ID)
+	if err != nil {
+		return latestFile{}, err
+	}
+	modInfoData.LatestFiles = mergeFiles(modInfoData.LatestFiles, files)
+	return findLatestFile(modInfoData, mcVersion, packLoaderType, channel)
+}
+
+// mergeFiles combines lists of files, keeping only the first file with each ID
+func mergeFiles(fileLists ...[]modFileInfo) []modFileInfo {
+	seen := make(map[int]bool)
+	var merged []modFileInfo
+	for _, files := range fileLists {
+		for _, v := range files {
+			if !seen[v.ID] {
+				seen[v.ID] = true
+				merged = append(merged, v)
+			}
+		}
+	}
+	return merged
+}
+
+// parseReleaseChannel converts a release channel name (release, beta or alpha) to the highest file type it accepts; if
+// no name is given, files from all release channels are accepted
+func parseReleaseChannel(name string) (int, error) {
+	switch strings.ToLower(name) {
+	case "release":
+		return fileTypeRelease, nil
+	case "beta":
+		return fileTypeBeta, nil
+	case "alpha", "":
+		return fileTypeAlpha, nil
+	}
+	return 0, fmt.Errorf("invalid release channel %s (must be release, beta or alpha)", name)
+}
+
+// getReleaseChannel gets the release channel for a mod, using the pack-wide default if the mod doesn't set one
+func getReleaseChannel(modChannel string) (int, error) {
+	if len(modChannel) > 0 {
+		return parseReleaseChannel(modChannel)
+	}
+	return parseReleaseChannel(viper.GetString("curseforge.release-channel"))
+}
@@ -0,0 +1,360 @@
+package curseforge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestMergeFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileLists [][]modFileInfo
+		wantIDs   []int
+		wantNames []string
+	}{
+		{
+			name:      "no files",
+			fileLists: [][]modFileInfo{nil, nil},
+		},
+		{
+			name: "overlapping lists",
+			fileLists: [][]modFileInfo{
+				{{ID: 3, FileName: "latest-3.jar"}, {ID: 1, FileName: "latest-1.jar"}},
+				{{ID: 1, FileName: "all-1.jar"}, {ID: 2, FileName: "all-2.jar"}, {ID: 3, FileName: "all-3.jar"}},
+			},
+			wantIDs:   []int{3, 1, 2},
+			wantNames: []string{"latest-3.jar", "latest-1.jar", "all-2.jar"},
+		},
+		{
+			name: "duplicates in one list",
+			fileLists: [][]modFileInfo{
+				{{ID: 5, FileName: "a.jar"}, {ID: 5, FileName: "b.jar"}},
+			},
+			wantIDs:   []int{5},
+			wantNames: []string{"a.jar"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeFiles(tt.fileLists...)
+			if len(merged) != len(tt.wantIDs) {
+				t.Fatalf("merged %d files, want %d", len(merged), len(tt.wantIDs))
+			}
+			for i, v := range merged {
+				if v.ID != tt.wantIDs[i] || v.FileName != tt.wantNames[i] {
+					t.Errorf("file %d is %d (%s), want %d (%s)", i, v.ID, v.FileName, tt.wantIDs[i], tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFindLatestFileWithAllFiles(t *testing.T) {
+	fabricFile := func(id int, mcVersion string) modFileInfo {
+		return modFileInfo{ID: id, FileName: "file-" + mcVersion + ".jar", FileType: fileTypeRelease, GameVersions: []string{mcVersion, "Fabric"}}
+	}
+	modInfoWithFiles := func(t *testing.T, modID int, latestFiles []modFileInfo, gameVersionLatestFiles string) modInfo {
+		var info modInfo
+		if err := json.Unmarshal([]byte(`{"gameVersionLatestFiles": [`+gameVersionLatestFiles+`]}`), &info); err != nil {
+			t.Fatal(err)
+		}
+		info.ID = modID
+		info.LatestFiles = latestFiles
+		return info
+	}
+
+	tests := []struct {
+		name            string
+		latestFiles     []modFileInfo
+		gameVersionJSON string
+		allFiles        []modFileInfo
+		wantID          int
+		wantFileInfo    bool
+		wantErr         bool
+	}{
+		{
+			name:         "compatible file in latest files",
+			latestFiles:  []modFileInfo{fabricFile(10, "1.18.1"), fabricFile(12, "1.19.2")},
+			allFiles:     []modFileInfo{fabricFile(9, "1.18.1")},
+			wantID:       10,
+			wantFileInfo: true,
+		},
+		{
+			name:         "newer compatible file from the files endpoint",
+			latestFiles:  []modFileInfo{fabricFile(10, "1.18.1"), fabricFile(12, "1.19.2")},
+			allFiles:     []modFileInfo{fabricFile(11, "1.18.1"), fabricFile(13, "1.18.1")},
+			wantID:       13,
+			wantFileInfo: true,
+		},
+		{
+			name:            "newer file from the files endpoint than game version latest files",
+			latestFiles:     []modFileInfo{fabricFile(10, "1.18.1")},
+			gameVersionJSON: `{"gameVersion": "1.18.1", "projectFileId": 15, "projectFileName": "newer.jar", "fileType": 1, "modLoader": 4}`,
+			allFiles:        []modFileInfo{fabricFile(16, "1.18.1")},
+			wantID:          16,
+			wantFileInfo:    true,
+		},
+		{
+			name:            "newer file in game version latest files",
+			latestFiles:     []modFileInfo{fabricFile(10, "1.18.1")},
+			gameVersionJSON: `{"gameVersion": "1.18.1", "projectFileId": 15, "projectFileName": "newer.jar", "fileType": 1, "modLoader": 4}`,
+			wantID:          15,
+		},
+		{
+			name:            "game version latest file with full info in latest files",
+			latestFiles:     []modFileInfo{fabricFile(10, "1.18.1"), fabricFile(15, "1.18.2")},
+			gameVersionJSON: `{"gameVersion": "1.18.1", "projectFileId": 15, "projectFileName": "file-1.18.2.jar", "fileType": 1, "modLoader": 4}`,
+			wantID:          15,
+			wantFileInfo:    true,
+		},
+		{
+			name:         "compatible file only in all files",
+			latestFiles:  []modFileInfo{fabricFile(12, "1.19.2")},
+			allFiles:     []modFileInfo{fabricFile(12, "1.19.2"), fabricFile(9, "1.18.1"), fabricFile(11, "1.18.1"), fabricFile(11, "1.18.1")},
+			wantID:       11,
+			wantFileInfo: true,
+		},
+		{
+			name:        "no compatible file",
+			latestFiles: []modFileInfo{fabricFile(12, "1.19.2")},
+			allFiles:    []modFileInfo{fabricFile(13, "1.19.2")},
+			wantErr:     true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modID := 1000 + i
+			// Set up the response of the files endpoint, so that it isn't queried
+			cacheSet("addon/"+strconv.Itoa(modID)+"/files", tt.allFiles)
+
+			info := modInfoWithFiles(t, modID, tt.latestFiles, tt.gameVersionJSON)
+			latest, err := findLatestFileWithAllFiles(context.Background(), info, "1.18.1", modloaderTypeFabric, fileTypeRelease)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, found file %d", latest.fileID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if latest.fileID != tt.wantID || latest.hasFileInfo != tt.wantFileInfo {
+				t.Errorf("found file %d (file info: %v), want %d (file info: %v)", latest.fileID, latest.hasFileInfo, tt.wantID, tt.wantFileInfo)
+			}
+			if latest.hasFileInfo && latest.fileInfo.ID != latest.fileID {
+				t.Errorf("file info is for file %d, not %d", latest.fileInfo.ID, latest.fileID)
+			}
+		})
+	}
+}
+
+func TestGetMinFileAgeCutoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		hours    int
+		wantZero bool
+	}{
+		{name: "unset", hours: 0, wantZero: true},
+		{name: "negative", hours: -5, wantZero: true},
+		{name: "one day", hours: 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.min-file-age", tt.hours)
+			defer viper.Set("curseforge.min-file-age", 0)
+
+			before := time.Now()
+			cutoff := getMinFileAgeCutoff()
+			after := time.Now()
+			if tt.wantZero {
+				if !cutoff.IsZero() {
+					t.Errorf("getMinFileAgeCutoff() = %v, want the zero time", cutoff)
+				}
+				return
+			}
+			age := time.Duration(tt.hours) * time.Hour
+			if cutoff.Before(before.Add(-age)) || cutoff.After(after.Add(-age)) {
+				t.Errorf("getMinFileAgeCutoff() = %v, want %v before now", cutoff, age)
+			}
+		})
+	}
+}
+
+func TestMinFileAge(t *testing.T) {
+	now := time.Now()
+	fabricFile := func(id int, age time.Duration) modFileInfo {
+		return modFileInfo{ID: id, FileType: fileTypeRelease, GameVersions: []string{"1.18.1", "Fabric"}, Date: cfDateFormat{now.Add(-age)}}
+	}
+	var gameVersionLatestFiles modInfo
+	if err := json.Unmarshal([]byte(`{"gameVersionLatestFiles": [{"gameVersion": "1.18.1", "projectFileId": 20, "projectFileName": "undated.jar", "fileType": 1, "modLoader": 4}]}`), &gameVersionLatestFiles); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		minAgeHours int
+		latestFiles []modFileInfo
+		wantID      int
+		wantErr     bool
+	}{
+		{
+			name:        "no minimum age",
+			latestFiles: []modFileInfo{fabricFile(10, 48*time.Hour), fabricFile(11, time.Hour)},
+			wantID:      20,
+		},
+		{
+			name:        "recent file skipped",
+			minAgeHours: 24,
+			latestFiles: []modFileInfo{fabricFile(10, 48*time.Hour), fabricFile(11, time.Hour)},
+			wantID:      10,
+		},
+		{
+			name:        "all files too recent",
+			minAgeHours: 24,
+			latestFiles: []modFileInfo{fabricFile(11, time.Hour)},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.min-file-age", tt.minAgeHours)
+			defer viper.Set("curseforge.min-file-age", 0)
+
+			info := modInfo{LatestFiles: tt.latestFiles, GameVersionLatestFiles: gameVersionLatestFiles.GameVersionLatestFiles}
+			latest, err := findLatestFile(info, "1.18.1", modloaderTypeFabric, fileTypeRelease)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, found file %d", latest.fileID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if latest.fileID != tt.wantID {
+				t.Errorf("found file %d, want %d", latest.fileID, tt.wantID)
+			}
+			// Files picked with --pick-file are also limited by the minimum age
+			cutoff := now.Add(-time.Duration(tt.minAgeHours) * time.Hour)
+			for _, v := range info.findMatchingFiles("1.18.1", modloaderTypeFabric, fileTypeRelease) {
+				if tt.minAgeHours > 0 && v.Date.After(cutoff) {
+					t.Errorf("findMatchingFiles() returned file %d, which is too recent", v.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetReleaseChannel(t *testing.T) {
+	tests := []struct {
+		name        string
+		modChannel  string
+		packChannel string
+		want        int
+		wantErr     bool
+	}{
+		{name: "unset", want: fileTypeAlpha},
+		{name: "pack channel", packChannel: "beta", want: fileTypeBeta},
+		{name: "mod channel overrides pack channel", modChannel: "Release", packChannel: "alpha", want: fileTypeRelease},
+		{name: "invalid mod channel", modChannel: "nightly", wantErr: true},
+		{name: "invalid pack channel", packChannel: "stable", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.release-channel", tt.packChannel)
+			defer viper.Set("curseforge.release-channel", "")
+
+			got, err := getReleaseChannel(tt.modChannel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getReleaseChannel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getReleaseChannel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindBestFileReleaseChannel(t *testing.T) {
+	info := modInfo{LatestFiles: []modFileInfo{
+		{ID: 1, FileType: fileTypeRelease, GameVersions: []string{"1.18.2"}},
+		{ID: 2, FileType: fileTypeBeta, GameVersions: []string{"1.18.2"}},
+		{ID: 3, FileType: fileTypeAlpha, GameVersions: []string{"1.18.2"}},
+	}}
+
+	tests := []struct {
+		channel int
+		wantID  int
+	}{
+		{channel: fileTypeRelease, wantID: 1},
+		{channel: fileTypeBeta, wantID: 2},
+		{channel: fileTypeAlpha, wantID: 3},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.channel), func(t *testing.T) {
+			file, err := info.findBestFile("1.18.2", modloaderTypeAny, tt.channel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.ID != tt.wantID {
+				t.Errorf("findBestFile() = file %d, want %d", file.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestGetLatestFilePreferServer(t *testing.T) {
+	universalFile := modFileInfo{ID: 10, FileName: "mod-universal.jar", FileType: fileTypeRelease, GameVersions: []string{"1.18.2", "Fabric", "Client", "Server"}}
+	clientFile := modFileInfo{ID: 11, FileName: "mod-client.jar", FileType: fileTypeRelease, GameVersions: []string{"1.18.2", "Fabric", "Client"}}
+	newerClientFile := modFileInfo{ID: 12, FileName: "mod-client-2.jar", FileType: fileTypeRelease, GameVersions: []string{"1.18.2", "Fabric", "Client"}}
+	newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/addon/1/files", "/addon/2/files":
+			writeTestJSON(t, w, []modFileInfo{universalFile, clientFile})
+		case "/addon/2/file/12":
+			writeTestJSON(t, w, newerClientFile)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	// Mod 2 has a newer client-only file that is only in GameVersionLatestFiles
+	info := modInfo{ID: 1, LatestFiles: []modFileInfo{universalFile, clientFile}}
+	var infoWithNewerFile modInfo
+	if err := json.Unmarshal([]byte(`{"id": 2, "gameVersionLatestFiles": [{"gameVersion": "1.18.2", "projectFileId": 12, "projectFileName": "mod-client-2.jar", "fileType": 1, "modLoader": 4}]}`), &infoWithNewerFile); err != nil {
+		t.Fatal(err)
+	}
+	infoWithNewerFile.LatestFiles = info.LatestFiles
+
+	tests := []struct {
+		name         string
+		info         modInfo
+		preferServer bool
+		wantID       int
+	}{
+		{name: "client pack", info: info, wantID: 11},
+		{name: "server pack", info: info, preferServer: true, wantID: 10},
+		{name: "client pack with newer file", info: infoWithNewerFile, wantID: 12},
+		{name: "server pack with newer client-only file", info: infoWithNewerFile, preferServer: true, wantID: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.install.prefer-server", tt.preferServer)
+			defer viper.Set("curseforge.install.prefer-server", false)
+
+			file, err := getLatestFile(context.Background(), tt.info, "1.18.2", 0, modloaderTypeFabric, fileTypeRelease)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.ID != tt.wantID {
+				t.Errorf("getLatestFile() = file %d, want %d", file.ID, tt.wantID)
+			}
+		})
+	}
+}
@@ -31,7 +31,7 @@ var importCmd = &cobra.Command{
 		// TODO: refactor/extract file checking?
 		if strings.HasPrefix(inputFile, "http") {
 			// TODO: implement
-			fmt.Println("HTTP not supported (yet)")
+			fmt.Fprintln(os.Stderr, "HTTP not supported (yet)")
 			os.Exit(1)
 		} else {
 			// Attempt to read from file
@@ -72,15 +72,15 @@ var importCmd = &cobra.Command{
 				if found {
 					f, err = os.Open(inputFile)
 					if err != nil {
-						fmt.Printf("Error opening file: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Error opening file: %s\n", err)
 						os.Exit(1)
 					}
 				} else {
-					fmt.Printf("Error opening file: %s\n", err)
-					fmt.Printf("Also attempted minecraftinstance.json: %s\n", errInstance)
-					fmt.Printf("Also attempted manifest.json: %s\n", errManifest)
+					fmt.Fprintf(os.Stderr, "Error opening file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Also attempted minecraftinstance.json: %s\n", errInstance)
+					fmt.Fprintf(os.Stderr, "Also attempted manifest.json: %s\n", errManifest)
 					if errCurse != nil {
-						fmt.Printf("Also attempted to load a Curse/Twitch modpack named \"%s\": %s\n", inputFile, errCurse)
+						fmt.Fprintf(os.Stderr, "Also attempted to load a Curse/Twitch modpack named \"%s\": %s\n", inputFile, errCurse)
 					}
 					os.Exit(1)
 				}
@@ -90,7 +90,7 @@ var importCmd = &cobra.Command{
 			buf := bufio.NewReader(f)
 			header, err := buf.Peek(2)
 			if err != nil {
-				fmt.Printf("Error reading file: %s\n", err)
+				fmt.Fprintf(os.Stderr, "Error reading file: %s\n", err)
 				os.Exit(1)
 			}
 
@@ -99,18 +99,18 @@ var importCmd = &cobra.Command{
 				// Read the whole file (as bufio doesn't work for zips)
 				zipData, err := ioutil.ReadAll(buf)
 				if err != nil {
-					fmt.Printf("Error reading file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error reading file: %s\n", err)
 					os.Exit(1)
 				}
 				// Get zip size
 				stat, err := f.Stat()
 				if err != nil {
-					fmt.Printf("Error reading file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error reading file: %s\n", err)
 					os.Exit(1)
 				}
 				zr, err := zip.NewReader(bytes.NewReader(zipData), stat.Size())
 				if err != nil {
-					fmt.Printf("Error parsing zip: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error parsing zip: %s\n", err)
 					os.Exit(1)
 				}
 
@@ -123,7 +123,7 @@ var importCmd = &cobra.Command{
 				}
 
 				if metaFile == nil {
-					fmt.Println("Can't find manifest.json or minecraftinstance.json, is this a valid pack?")
+					fmt.Fprintln(os.Stderr, "Can't find manifest.json or minecraftinstance.json, is this a valid pack?")
 					os.Exit(1)
 				}
 
@@ -135,7 +135,7 @@ var importCmd = &cobra.Command{
 
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println("Failed to load existing pack, creating a new one...")
+			fmt.Fprintln(core.Output, "Failed to load existing pack, creating a new one...")
 
 			// Create a new modpack
 			indexFilePath := viper.GetString("init.index-file")
@@ -144,12 +144,12 @@ var importCmd = &cobra.Command{
 				// Create file
 				err = ioutil.WriteFile(indexFilePath, []byte{}, 0644)
 				if err != nil {
-					fmt.Printf("Error creating index file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error creating index file: %s\n", err)
 					os.Exit(1)
 				}
-				fmt.Println(indexFilePath + " created!")
+				fmt.Fprintln(core.Output, indexFilePath+" created!")
 			} else if err != nil {
-				fmt.Printf("Error checking index file: %s\n", err)
+				fmt.Fprintf(os.Stderr, "Error checking index file: %s\n", err)
 				os.Exit(1)
 			}
 
@@ -171,16 +171,16 @@ var importCmd = &cobra.Command{
 			for component, version := range packImport.Versions() {
 				packVersion, ok := pack.Versions[component]
 				if !ok {
-					fmt.Println("Set " + core.ComponentToFriendlyName(component) + " version to " + version)
+					fmt.Fprintln(core.Output, "Set "+core.ComponentToFriendlyName(component)+" version to "+version)
 				} else if packVersion != version {
-					fmt.Println("Set " + core.ComponentToFriendlyName(component) + " version to " + version + " (previously " + packVersion + ")")
+					fmt.Fprintln(core.Output, "Set "+core.ComponentToFriendlyName(component)+" version to "+version+" (previously "+packVersion+")")
 				}
 				pack.Versions[component] = version
 			}
 		}
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
@@ -190,11 +190,11 @@ var importCmd = &cobra.Command{
 			modIDs[i] = v.ProjectID
 		}
 
-		fmt.Println("Querying Curse API for mod info...")
+		fmt.Fprintln(core.Output, "Querying Curse API for mod info...")
 
-		modInfos, err := getModInfoMultiple(modIDs)
+		modInfos, err := getModInfoMultiple(cmd.Context(), modIDs)
 		if err != nil {
-			fmt.Printf("Failed to obtain mod information: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to obtain mod information: %s\n", err)
 			os.Exit(1)
 		}
 
@@ -214,7 +214,7 @@ var importCmd = &cobra.Command{
 		for _, v := range modsList {
 			modInfoValue, ok := modInfosMap[v.ProjectID]
 			if !ok {
-				fmt.Printf("Failed to obtain mod information for addon/file IDs %d/%d\n", v.ProjectID, v.FileID)
+				fmt.Fprintf(os.Stderr, "Failed to obtain mod information for addon/file IDs %d/%d\n", v.ProjectID, v.FileID)
 				continue
 			}
 
@@ -234,11 +234,11 @@ var importCmd = &cobra.Command{
 		}
 
 		// 2nd pass: query files that weren't in the previous results
-		fmt.Println("Querying Curse API for file info...")
+		fmt.Fprintln(core.Output, "Querying Curse API for file info...")
 
-		modFileInfos, err := getFileInfoMultiple(remainingFileIDs)
+		modFileInfos, err := getFileInfoMultiple(cmd.Context(), remainingFileIDs)
 		if err != nil {
-			fmt.Printf("Failed to obtain mod file information: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to obtain mod file information: %s\n", err)
 			os.Exit(1)
 		}
 
@@ -249,22 +249,27 @@ var importCmd = &cobra.Command{
 		}
 
 		// 3rd pass: create mod files for every file
+		var unavailable []error
 		for _, v := range modsList {
 			modInfoValue, ok := modInfosMap[v.ProjectID]
 			if !ok {
-				fmt.Printf("Failed to obtain mod information for addon/file IDs %d/%d\n", v.ProjectID, v.FileID)
+				fmt.Fprintf(os.Stderr, "Failed to obtain mod information for addon/file IDs %d/%d\n", v.ProjectID, v.FileID)
 				continue
 			}
 
 			modFileInfoValue, ok := modFileInfosMap[v.FileID]
 			if !ok {
-				fmt.Printf("Failed to obtain mod file information for addon/file IDs %d/%d\n", v.ProjectID, v.FileID)
+				fmt.Fprintf(os.Stderr, "Failed to obtain mod file information for addon/file IDs %d/%d\n", v.ProjectID, v.FileID)
 				continue
 			}
 
-			err = createModFile(modInfoValue, modFileInfoValue, &index, v.OptionalDisabled)
+			err = createModFile(modInfoValue, modFileInfoValue, &index, v.OptionalDisabled, "", modFileInfoValue.getImportSide())
 			if err != nil {
-				fmt.Printf("Failed to save mod \"%s\": %s\n", modInfoValue.Name, err)
+				if errors.Is(err, ErrDownloadUnavailable) {
+					unavailable = append(unavailable, err)
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "Failed to save mod \"%s\": %s\n", modInfoValue.Name, err)
 				os.Exit(1)
 			}
 
@@ -275,16 +280,22 @@ var importCmd = &cobra.Command{
 				referencedModPaths = append(referencedModPaths, ref)
 			}
 
-			fmt.Printf("Imported mod \"%s\" successfully!\n", modInfoValue.Name)
+			fmt.Fprintf(core.Output, "Imported mod \"%s\" successfully!\n", modInfoValue.Name)
 			successes++
 		}
 
-		fmt.Printf("Successfully imported %d/%d mods!\n", successes, len(modsList))
+		fmt.Fprintf(core.Output, "Successfully imported %d/%d mods!\n", successes, len(modsList))
+		if len(unavailable) > 0 {
+			fmt.Fprintf(os.Stderr, "The following %d mods could not be imported:\n", len(unavailable))
+			for _, err := range unavailable {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
 
-		fmt.Println("Reading override files...")
+		fmt.Fprintln(core.Output, "Reading override files...")
 		filesList, err := packImport.GetFiles()
 		if err != nil {
-			fmt.Printf("Failed to read override files: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to read override files: %s\n", err)
 			os.Exit(1)
 		}
 
@@ -302,12 +313,12 @@ var importCmd = &cobra.Command{
 					}
 				}
 				if found {
-					fmt.Printf("Ignored file \"%s\" (referenced by metadata)\n", filePath)
+					fmt.Fprintf(core.Output, "Ignored file \"%s\" (referenced by metadata)\n", filePath)
 					successes++
 					continue
 				}
 				if v.Name() == "manifest.json" || v.Name() == "minecraftinstance.json" || v.Name() == ".curseclient" {
-					fmt.Printf("Ignored file \"%s\"\n", v.Name())
+					fmt.Fprintf(core.Output, "Ignored file \"%s\"\n", v.Name())
 					successes++
 					continue
 				}
@@ -321,56 +332,56 @@ var importCmd = &cobra.Command{
 					f, err = os.Create(filePath)
 				}
 				if err != nil {
-					fmt.Printf("Failed to write file \"%s\": %s\n", filePath, err)
+					fmt.Fprintf(os.Stderr, "Failed to write file \"%s\": %s\n", filePath, err)
 					if err2 != nil {
-						fmt.Printf("Failed to create directories: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to create directories: %s\n", err)
 					}
 					continue
 				}
 			}
 			src, err := v.Open()
 			if err != nil {
-				fmt.Printf("Failed to read file \"%s\": %s\n", filePath, err)
+				fmt.Fprintf(os.Stderr, "Failed to read file \"%s\": %s\n", filePath, err)
 				f.Close()
 				continue
 			}
 			_, err = io.Copy(f, src)
 			if err != nil {
-				fmt.Printf("Failed to copy file \"%s\": %s\n", filePath, err)
+				fmt.Fprintf(os.Stderr, "Failed to copy file \"%s\": %s\n", filePath, err)
 				f.Close()
 				src.Close()
 				continue
 			}
 
-			fmt.Printf("Copied file \"%s\" successfully!\n", filePath)
+			fmt.Fprintf(core.Output, "Copied file \"%s\" successfully!\n", filePath)
 			f.Close()
 			src.Close()
 			successes++
 		}
 		if len(filesList) > 0 {
-			fmt.Printf("Successfully copied %d/%d files!\n", successes, len(filesList))
+			fmt.Fprintf(core.Output, "Successfully copied %d/%d files!\n", successes, len(filesList))
 			err = index.Refresh()
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 		} else {
-			fmt.Println("No files copied!")
+			fmt.Fprintln(core.Output, "No files copied!")
 		}
 
 		err = index.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.UpdateIndexHash()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 	},
@@ -2,25 +2,26 @@ package curseforge
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/sahilm/fuzzy"
 	"github.com/spf13/viper"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/packwiz/packwiz/core"
 	"github.com/spf13/cobra"
 	"gopkg.in/dixonwille/wmenu.v4"
 )
 
-const maxCycles = 20
-
-type installableDep struct {
-	modInfo
-	fileInfo modFileInfo
-}
-
 // installCmd represents the install command
 var installCmd = &cobra.Command{
 	Use:     "install [mod]",
@@ -30,18 +31,32 @@ var installCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		mcVersion, err := pack.GetMCVersion()
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			// If the pack doesn't have a Minecraft version, it can be found from an existing mod file
+			jarPath := viper.GetString("curseforge.install.game-version-from-jar")
+			if jarPath == "" {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			mcVersion, err = inferGameVersionFromJar(cmd.Context(), jarPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(core.Output, "Using Minecraft version %s from %s\n", mcVersion, jarPath)
+		}
+
+		if extraVersions, _ := cmd.Flags().GetStringSlice("accept-game-versions"); len(extraVersions) > 0 {
+			acceptGameVersions(extraVersions)
 		}
 
 		var done bool
@@ -53,22 +68,39 @@ var installCmd = &cobra.Command{
 			fileID = fileIDFlag
 			done = true
 		}
+		// If a mod file is provided, adopt it by looking up its fingerprint
+		fingerprintPath := viper.GetString("curseforge.install.fingerprint")
+		if fingerprintPath != "" && !done {
+			modID, fileID, err = getIDsFromFingerprint(cmd.Context(), fingerprintPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			done = true
+		}
 		if (len(args) == 0 || len(args[0]) == 0) && !done {
-			fmt.Println("You must specify a mod.")
+			fmt.Fprintln(os.Stderr, "You must specify a mod.")
 			os.Exit(1)
 		}
 		// If there are more than 1 argument, go straight to searching - URLs/Slugs should not have spaces!
 		if !done && len(args) == 1 {
-			done, modID, fileID, err = getFileIDsFromString(args[0])
+			done, modID, fileID, err = getFileIDsFromString(cmd.Context(), args[0])
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 
 			if !done {
-				done, modID, err = getModIDFromString(args[0])
-				// Ignore error, go to search instead (e.g. lowercase to search instead of as a slug)
+				done, modID, err = getModIDFromString(cmd.Context(), args[0])
 				if err != nil {
+					// If the mod wasn't found, go to search instead (e.g. lowercase to search instead of as a slug)
+					if !errors.Is(err, ErrModNotFound) {
+						if installFromOtherSource(cmd.Context(), args[0], pack, err) {
+							return
+						}
+						fmt.Fprintln(os.Stderr, err)
+						os.Exit(1)
+					}
 					done = false
 				}
 			}
@@ -79,7 +111,14 @@ var installCmd = &cobra.Command{
 
 		if !done {
 			var cancelled bool
-			cancelled, modInfoData = searchCurseforgeInternal(args, mcVersion, getLoader(pack))
+			cancelled, modInfoData, err = searchCurseforgeInternal(cmd.Context(), args, mcVersion, getLoader(pack))
+			if err != nil {
+				if installFromOtherSource(cmd.Context(), strings.Join(args, " "), pack, err) {
+					return
+				}
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
 			if cancelled {
 				return
 			}
@@ -90,168 +129,228 @@ var installCmd = &cobra.Command{
 
 		if !done {
 			if err == nil {
-				fmt.Println("No mods found!")
+				fmt.Fprintln(os.Stderr, "No mods found!")
 				os.Exit(1)
 			}
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
 		if !modInfoObtained {
-			modInfoData, err = getModInfo(modID)
+			modInfoData, err = getModInfo(cmd.Context(), modID)
+			if err != nil {
+				if len(args) > 0 && !errors.Is(err, ErrModNotFound) && installFromOtherSource(cmd.Context(), strings.Join(args, " "), pack, err) {
+					return
+				}
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
+		if !confirmDistribution(modInfoData) {
+			fmt.Fprintln(core.Output, "Cancelled!")
+			return
+		}
+
+		// The release channel is only saved in the mod metadata if it was explicitly given
+		releaseChannel, _ := cmd.Flags().GetString("release-channel")
+		channel, err := getReleaseChannel(releaseChannel)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		depChannel, err := getReleaseChannel("")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		var since time.Time
+		if sinceStr := viper.GetString("curseforge.install.since"); sinceStr != "" {
+			since, err = parseSinceDate(sinceStr)
 			if err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 				os.Exit(1)
 			}
 		}
 
 		var fileInfoData modFileInfo
-		fileInfoData, err = getLatestFile(modInfoData, mcVersion, fileID, getLoader(pack))
+		if fileID == 0 && viper.GetBool("curseforge.install.pick-file") {
+			var cancelled bool
+			cancelled, fileInfoData, err = pickFile(modInfoData, mcVersion, getLoader(pack), channel)
+			if cancelled {
+				return
+			}
+		} else {
+			fileInfoData, err = getLatestFile(cmd.Context(), modInfoData, mcVersion, fileID, getLoader(pack), channel)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		// The side given when installing takes priority, then the environments the file is tagged with
+		side, err := fileInfoData.getInstallSide(viper.GetString("curseforge.install.side"))
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
+		// Mods that haven't had a compatible file released recently may be abandoned
+		if !since.IsZero() && fileID == 0 && !confirmFileDate(modInfoData, fileInfoData, since) {
+			fmt.Fprintln(core.Output, "Cancelled!")
+			return
+		}
+
+		var depsToInstall []installableDep
 		if len(fileInfoData.Dependencies) > 0 {
-			var depsInstallable []installableDep
-			var depIDPendingQueue []int
-			for _, dep := range fileInfoData.Dependencies {
-				if dep.Type == dependencyTypeRequired {
-					depIDPendingQueue = append(depIDPendingQueue, dep.ModID)
-				}
+			fmt.Fprintln(core.Output, "Finding dependencies...")
+			deps, err := resolveDependencies(cmd.Context(), modInfoData, fileInfoData, index, mcVersion, getLoader(pack), depChannel, viper.GetInt("curseforge.install.dependency-depth"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
 			}
 
-			if len(depIDPendingQueue) > 0 {
-				fmt.Println("Finding dependencies...")
-
-				cycles := 0
-				var installedIDList []int
-				for len(depIDPendingQueue) > 0 && cycles < maxCycles {
-					if installedIDList == nil {
-						// Get modids of all mods
-						for _, modPath := range index.GetAllMods() {
-							mod, err := core.LoadMod(modPath)
-							if err == nil {
-								data, ok := mod.GetParsedUpdateData("curseforge")
-								if ok {
-									updateData, ok := data.(cfUpdateData)
-									if ok {
-										if updateData.ProjectID > 0 {
-											installedIDList = append(installedIDList, updateData.ProjectID)
-										}
-									}
-								}
-							}
-						}
-					}
-
-					// Remove installed IDs from dep queue
-					i := 0
-					for _, id := range depIDPendingQueue {
-						contains := false
-						for _, id2 := range installedIDList {
-							if id == id2 {
-								contains = true
-								break
-							}
-						}
-						for _, data := range depsInstallable {
-							if id == data.ID {
-								contains = true
-								break
-							}
-						}
-						if !contains {
-							depIDPendingQueue[i] = id
-							i++
-						}
-					}
-					depIDPendingQueue = depIDPendingQueue[:i]
+			for _, v := range deps.cycles {
+				fmt.Fprintf(os.Stderr, "Warning: circular dependency found (%s); each mod will only be installed once\n", v)
+			}
+			if deps.truncated {
+				fmt.Fprintln(core.Output, "Some dependencies were not resolved, as they are deeper than the maximum dependency depth")
+			}
 
-					depInfoData, err := getModInfoMultiple(depIDPendingQueue)
-					if err != nil {
-						fmt.Printf("Error retrieving dependency data: %s\n", err.Error())
+			if len(deps.required) > 0 {
+				fmt.Fprintln(core.Output, "Dependencies found:")
+				totalSize := int64(fileInfoData.Length)
+				for _, v := range deps.required {
+					totalSize += int64(v.fileInfo.Length)
+					if v.AllowModDistribution != nil && !*v.AllowModDistribution {
+						fmt.Fprintf(core.Output, "%s (%s, redistribution not allowed by the author)\n", v.Name, core.FormatFileSize(int64(v.fileInfo.Length)))
+						continue
 					}
-					depIDPendingQueue = depIDPendingQueue[:0]
-
-					for _, currData := range depInfoData {
-						depFileInfo, err := getLatestFile(currData, mcVersion, 0, getLoader(pack))
-						if err != nil {
-							fmt.Printf("Error retrieving dependency data: %s\n", err.Error())
-							continue
-						}
+					fmt.Fprintf(core.Output, "%s (%s)\n", v.Name, core.FormatFileSize(int64(v.fileInfo.Length)))
+				}
+				fmt.Fprintf(core.Output, "Total download size (including %s): %s\n", modInfoData.Name, core.FormatFileSize(totalSize))
 
-						for _, dep := range depFileInfo.Dependencies {
-							if dep.Type == dependencyTypeRequired {
-								depIDPendingQueue = append(depIDPendingQueue, dep.ModID)
-							}
-						}
+				fmt.Fprint(core.PromptOutput, "Would you like to install them? [Y/n]: ")
+				answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
 
-						depsInstallable = append(depsInstallable, installableDep{
-							currData, depFileInfo,
-						})
-					}
+				ansNormal := strings.ToLower(strings.TrimSpace(answer))
+				if !(len(ansNormal) > 0 && ansNormal[0] == 'n') {
+					depsToInstall = append(depsToInstall, deps.required...)
+				}
+			} else if deps.hasRequired {
+				fmt.Fprintln(core.Output, "All dependencies are already installed!")
+			}
 
-					cycles++
+			if len(deps.optional) > 0 {
+				queued := map[int]bool{modInfoData.ID: true}
+				for _, v := range depsToInstall {
+					queued[v.ID] = true
 				}
-				if cycles >= maxCycles {
-					fmt.Println("Dependencies recurse too deeply! Try increasing maxCycles.")
+				optionalDeps, err := selectOptionalDependencies(cmd.Context(), deps.optional, queued, index, mcVersion, getLoader(pack), depChannel)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
 					os.Exit(1)
 				}
+				depsToInstall = append(depsToInstall, optionalDeps...)
+			}
+		}
 
-				if len(depsInstallable) > 0 {
-					fmt.Println("Dependencies found:")
-					for _, v := range depsInstallable {
-						fmt.Println(v.Name)
-					}
+		// Dependencies need the same confirmation as the mod itself if they can't be redistributed
+		i := 0
+		for _, v := range depsToInstall {
+			if confirmDistribution(v.modInfo) {
+				depsToInstall[i] = v
+				i++
+			} else {
+				fmt.Fprintf(core.Output, "Skipping dependency \"%s\"\n", v.Name)
+			}
+		}
+		depsToInstall = depsToInstall[:i]
 
-					fmt.Print("Would you like to install them? [Y/n]: ")
-					answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
-					if err != nil {
-						fmt.Println(err)
-						os.Exit(1)
-					}
+		// Every file is verified before any metadata is written, so that a failure doesn't leave a partial install
+		for _, v := range depsToInstall {
+			err = verifyFileDownload(cmd.Context(), v.fileInfo)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		err = verifyFileDownload(cmd.Context(), fileInfoData)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 
-					ansNormal := strings.ToLower(strings.TrimSpace(answer))
-					if !(len(ansNormal) > 0 && ansNormal[0] == 'n') {
-						for _, v := range depsInstallable {
-							err = createModFile(v.modInfo, v.fileInfo, &index, false)
-							if err != nil {
-								fmt.Println(err)
-								os.Exit(1)
-							}
-							fmt.Printf("Dependency \"%s\" successfully installed! (%s)\n", v.modInfo.Name, v.fileInfo.FileName)
-						}
-					}
-				} else {
-					fmt.Println("All dependencies are already installed!")
+		var unavailable []error
+		for _, v := range depsToInstall {
+			// Dependencies use the side they are tagged with rather than the side given for the installed mod
+			depSide, err := v.fileInfo.getInstallSide("")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			err = createModFile(v.modInfo, v.fileInfo, &index, false, "", depSide)
+			if err != nil {
+				if errors.Is(err, ErrDownloadUnavailable) {
+					unavailable = append(unavailable, err)
+					continue
 				}
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(core.Output, "Dependency \"%s\" successfully installed! (%s)\n", v.modInfo.Name, v.fileInfo.FileName)
+		}
+		if len(unavailable) > 0 {
+			fmt.Fprintf(os.Stderr, "The following %d dependencies could not be installed:\n", len(unavailable))
+			for _, err := range unavailable {
+				fmt.Fprintln(os.Stderr, err)
 			}
 		}
 
-		err = createModFile(modInfoData, fileInfoData, &index, false)
+		err = createModFile(modInfoData, fileInfoData, &index, false, releaseChannel, side)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		absFingerprintPath, err := adoptedFileToRemove(&index, fingerprintPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
 		err = index.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.UpdateIndexHash()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		err = pack.Write()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
-		fmt.Printf("Mod \"%s\" successfully installed! (%s)\n", modInfoData.Name, fileInfoData.FileName)
+		// Only delete the adopted file once the metadata file and index have been written successfully
+		if absFingerprintPath != "" {
+			err = os.Remove(absFingerprintPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		} else if fingerprintPath != "" {
+			fmt.Fprintf(core.Output, "%s was kept; remove it (or use --remove-file) so that the mod isn't in the pack twice\n", fingerprintPath)
+		}
+
+		fmt.Fprintf(core.Output, "Mod \"%s\" successfully installed! (%s, %s)\n", modInfoData.Name, fileInfoData.FileName, core.FormatFileSize(int64(fileInfoData.Length)))
 	},
 }
 
@@ -266,8 +365,11 @@ func (r modResultsList) Len() int {
 	return len(r)
 }
 
-func searchCurseforgeInternal(args []string, mcVersion string, packLoaderType int) (bool, modInfo) {
-	fmt.Println("Searching CurseForge...")
+// searchMoreResults is used as a menu value to request the next page of search results
+type searchMoreResults struct{}
+
+func searchCurseforgeInternal(ctx context.Context, args []string, mcVersion string, packLoaderType int) (bool, modInfo, error) {
+	fmt.Fprintln(core.Output, "Searching CurseForge...")
 	searchTerm := strings.Join(args, " ")
 
 	// If there are more than one acceptable version, we shouldn't filter by game version at all (as we can't filter by multiple)
@@ -275,22 +377,37 @@ func searchCurseforgeInternal(args []string, mcVersion string, packLoaderType in
 	if len(viper.GetStringSlice("acceptable-game-versions")) > 0 {
 		filterGameVersion = ""
 	}
-	results, err := getSearch(searchTerm, filterGameVersion, packLoaderType)
-	if err != nil {
-		fmt.Println(err)
+
+	searchPageSize := viper.GetInt("curseforge.install.max-results")
+	if searchPageSize <= 0 {
+		fmt.Fprintln(os.Stderr, "The maximum number of search results must be greater than 0!")
 		os.Exit(1)
 	}
-	if len(results) == 0 {
-		fmt.Println("No mods found!")
-		os.Exit(1)
-		return false, modInfo{}
-	} else if len(results) == 1 {
-		return false, results[0]
-	} else {
+
+	searchIndex := 0
+	for {
+		results, hasMore, err := getSearch(ctx, searchTerm, filterGameVersion, packLoaderType, searchIndex, searchPageSize)
+		if err != nil {
+			return false, modInfo{}, err
+		}
+		if len(results) == 0 {
+			// Search for mods for the fallback loader if there are none for this loader (e.g. Fabric mods on Quilt)
+			if fallbackLoaderType, ok := getFallbackLoaderType(packLoaderType); ok && searchIndex == 0 {
+				packLoaderType = fallbackLoaderType
+				continue
+			}
+			fmt.Fprintln(os.Stderr, "No mods found!")
+			os.Exit(1)
+			return false, modInfo{}, nil
+		} else if len(results) == 1 && searchIndex == 0 && !hasMore && !viper.GetBool("curseforge.install.always-prompt") {
+			return false, results[0], nil
+		}
+
 		// Fuzzy search on results list
 		fuzzySearchResults := fuzzy.FindFrom(searchTerm, modResultsList(results))
 
 		menu := wmenu.NewMenu("Choose a number:")
+		menu.ChangeReaderWriter(os.Stdin, core.PromptOutput, os.Stderr)
 
 		menu.Option("Cancel", nil, false, nil)
 		if len(fuzzySearchResults) == 0 {
@@ -302,15 +419,24 @@ func searchCurseforgeInternal(args []string, mcVersion string, packLoaderType in
 				menu.Option(results[v.Index].Name, results[v.Index], i == 0, nil)
 			}
 		}
+		if hasMore {
+			fmt.Fprintf(core.Output, "Showing %d results; more results are available\n", len(results))
+			menu.Option("Show more results", searchMoreResults{}, false, nil)
+		}
 
 		var modInfoData modInfo
 		var cancelled bool
+		var showMore bool
 		menu.Action(func(menuRes []wmenu.Opt) error {
 			if len(menuRes) != 1 || menuRes[0].Value == nil {
-				fmt.Println("Cancelled!")
+				fmt.Fprintln(core.Output, "Cancelled!")
 				cancelled = true
 				return nil
 			}
+			if _, ok := menuRes[0].Value.(searchMoreResults); ok {
+				showMore = true
+				return nil
+			}
 
 			// Why is variable shadowing a thing!!!!
 			var ok bool
@@ -322,55 +448,408 @@ func searchCurseforgeInternal(args []string, mcVersion string, packLoaderType in
 		})
 		err = menu.Run()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
 		if cancelled {
-			return true, modInfo{}
+			return true, modInfo{}, nil
+		}
+		if showMore {
+			searchIndex += searchPageSize
+			continue
 		}
-		return false, modInfoData
+		return false, modInfoData, nil
 	}
 }
 
-func getLatestFile(modInfoData modInfo, mcVersion string, fileID int, packLoaderType int) (modFileInfo, error) {
-	// For snapshots, curseforge doesn't put them in GameVersionLatestFiles
-	if fileID == 0 {
-		var fileInfoData modFileInfo
-		fileInfoObtained := false
-
-		for _, v := range modInfoData.LatestFiles {
-			// Choose "newest" version by largest ID
-			if matchGameVersions(mcVersion, v.GameVersions) && v.ID > fileID && matchLoaderTypeFileInfo(packLoaderType, v) {
-				fileID = v.ID
-				fileInfoData = v
-				fileInfoObtained = true
-			}
+// installFromOtherSource offers to install a mod from another source (e.g. Modrinth) when it couldn't be looked up on
+// CurseForge, returning true if it was installed
+func installFromOtherSource(ctx context.Context, query string, pack core.Pack, cfErr error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "Failed to look up the mod on CurseForge: %s\n", cfErr)
+
+	var names []string
+	for k := range core.ModSources {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		found, ok, err := core.ModSources[name].FindMod(ctx, query, pack)
+		if err != nil || !ok {
+			continue
 		}
-		// TODO: change to timestamp-based comparison??
-		for _, v := range modInfoData.GameVersionLatestFiles {
-			// Choose "newest" version by largest ID
-			if matchGameVersion(mcVersion, v.GameVersion) && v.ID > fileID && matchLoaderType(packLoaderType, v.Modloader) {
-				fileID = v.ID
-				fileInfoObtained = false // Make sure we get the file info
-			}
+
+		fmt.Fprintf(core.PromptOutput, "Would you like to install %s (%s) from %s instead? [Y/n]: ", found.Name, found.URL, name)
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		ansNormal := strings.ToLower(strings.TrimSpace(answer))
+		if len(ansNormal) > 0 && ansNormal[0] == 'n' {
+			continue
+		}
+
+		err = found.Install()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed installing mod: %s\n", err)
+			os.Exit(1)
+		}
+		return true
+	}
+	return false
+}
+
+// pickFile asks the user to choose one of the files of a mod that match the Minecraft version, loader and release
+// channel; if there is only one, it is chosen without asking unless the always-prompt option is set
+func pickFile(modInfoData modInfo, mcVersion string, packLoaderType int, channel int) (bool, modFileInfo, error) {
+	files := modInfoData.findMatchingFiles(mcVersion, packLoaderType, channel)
+	if len(files) == 0 {
+		return false, modFileInfo{}, errNoFileAvailable
+	}
+	if len(files) == 1 && !viper.GetBool("curseforge.install.always-prompt") {
+		return false, files[0], nil
+	}
+
+	menu := wmenu.NewMenu("Choose a file:")
+	menu.ChangeReaderWriter(os.Stdin, core.PromptOutput, os.Stderr)
+	menu.Option("Cancel", nil, false, nil)
+	for i, v := range files {
+		menu.Option(v.FileName, v, i == 0, nil)
+	}
+
+	var fileInfoData modFileInfo
+	var cancelled bool
+	menu.Action(func(menuRes []wmenu.Opt) error {
+		if len(menuRes) != 1 || menuRes[0].Value == nil {
+			fmt.Fprintln(core.Output, "Cancelled!")
+			cancelled = true
+			return nil
 		}
-		if fileInfoObtained {
-			return fileInfoData, nil
+		var ok bool
+		fileInfoData, ok = menuRes[0].Value.(modFileInfo)
+		if !ok {
+			return errors.New("error converting interface from wmenu")
 		}
+		return nil
+	})
+	err := menu.Run()
+	if err != nil {
+		return false, modFileInfo{}, err
 	}
+	return cancelled, fileInfoData, nil
+}
 
+func getLatestFile(ctx context.Context, modInfoData modInfo, mcVersion string, fileID int, packLoaderType int, channel int) (modFileInfo, error) {
+	var latest latestFile
 	if fileID == 0 {
-		return modFileInfo{}, errors.New("mod not available for the configured Minecraft version(s) (use the acceptable-game-versions option to accept more) or loader")
+		var err error
+		latest, err = findLatestFileWithAllFiles(ctx, modInfoData, mcVersion, packLoaderType, channel)
+		if err != nil {
+			return modFileInfo{}, err
+		}
+		if latest.hasFileInfo {
+			return latest.fileInfo, nil
+		}
+		fileID = latest.fileID
 	}
 
-	fileInfoData, err := getFileInfo(modInfoData.ID, fileID)
+	fileInfoData, err := getFileInfo(ctx, modInfoData.ID, fileID)
 	if err != nil {
 		return modFileInfo{}, err
 	}
+	// GameVersionLatestFiles doesn't say which environment a file is for, so check it now
+	if viper.GetBool("curseforge.install.prefer-server") && isClientOnlyFile(fileInfoData) &&
+		latest.hasFallback && !isClientOnlyFile(latest.fallback) {
+		return latest.fallback, nil
+	}
 	return fileInfoData, nil
 }
 
+// acceptGameVersions accepts files for additional Minecraft versions for the rest of this run, in the same way as the
+// acceptable-game-versions option
+func acceptGameVersions(versions []string) {
+	viper.Set("acceptable-game-versions", append(viper.GetStringSlice("acceptable-game-versions"), versions...))
+}
+
+// confirmDistribution shows whether a mod can be redistributed, and if it can't asks the user whether to continue,
+// unless the accept-license option is set
+func confirmDistribution(modInfoData modInfo) bool {
+	if modInfoData.AllowModDistribution == nil || *modInfoData.AllowModDistribution {
+		return true
+	}
+
+	fmt.Fprintf(os.Stderr, "The author of %s doesn't allow it to be redistributed; check the license at %s before distributing your pack\n", modInfoData.Name, modInfoData.WebsiteURL)
+	if viper.GetBool("curseforge.install.accept-license") {
+		return true
+	}
+
+	fmt.Fprint(core.PromptOutput, "Would you like to install it anyway? [Y/n]: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ansNormal := strings.ToLower(strings.TrimSpace(answer))
+	return !(len(ansNormal) > 0 && ansNormal[0] == 'n')
+}
+
+// confirmFileDate checks whether a file was released after since, and if it wasn't asks the user whether to install it
+// anyway (taking the end of input as "no")
+func confirmFileDate(modInfoData modInfo, fileInfoData modFileInfo, since time.Time) bool {
+	if !fileInfoData.Date.Before(since) {
+		return true
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: the latest compatible file of %s (%s) was released on %s, before %s\n", modInfoData.Name,
+		fileInfoData.FileName, fileInfoData.Date.Format("2006-01-02"), since.Format("2006-01-02"))
+	fmt.Fprint(core.PromptOutput, "Would you like to install it anyway? [y/N]: ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ansNormal := strings.ToLower(strings.TrimSpace(answer))
+	return len(ansNormal) > 0 && ansNormal[0] == 'y'
+}
+
+var mcVersionRegex = regexp.MustCompile("^\\d+\\.\\d+(?:\\.\\d+)?$")
+
+// isInteractive returns whether the user can be asked questions; it can be replaced in tests
+var isInteractive = core.IsInteractive
+
+// selectOptionalDependencies asks whether each of the given optional dependencies should be installed (taking the end
+// of input as "no"), returning the latest compatible file of each one that is accepted, along with the required
+// dependencies of these files. Mods in queued, which are already going to be installed, are not returned again.
+// If the no-optional-prompt option is set or input isn't a terminal, the dependencies are listed without asking, and
+// none of them are installed.
+func selectOptionalDependencies(ctx context.Context, optional []modInfo, queued map[int]bool, index core.Index, mcVersion string, packLoaderType int, channel int) ([]installableDep, error) {
+	if viper.GetBool("curseforge.install.no-optional-prompt") || !isInteractive() {
+		fmt.Fprintln(core.Output, "Optional dependencies (not installed automatically):")
+		for _, v := range optional {
+			fmt.Fprintln(core.Output, v.Name)
+		}
+		return nil, nil
+	}
+
+	var selected []installableDep
+	reader := bufio.NewReader(os.Stdin)
+	for _, v := range optional {
+		fmt.Fprintf(core.PromptOutput, "Would you like to install the optional dependency \"%s\"? [y/N]: ", v.Name)
+		answer, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		ansNormal := strings.ToLower(strings.TrimSpace(answer))
+		if !(len(ansNormal) > 0 && ansNormal[0] == 'y') {
+			if errors.Is(err, io.EOF) {
+				fmt.Fprintln(core.PromptOutput)
+				break
+			}
+			continue
+		}
+
+		if queued[v.ID] {
+			continue
+		}
+		fileInfoData, err := getLatestFile(ctx, v, mcVersion, 0, packLoaderType, channel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to install optional dependency \"%s\": %s\n", v.Name, err)
+			continue
+		}
+		queued[v.ID] = true
+		selected = append(selected, installableDep{v, fileInfoData})
+
+		deps, err := resolveDependencies(ctx, v, fileInfoData, index, mcVersion, packLoaderType, channel, viper.GetInt("curseforge.install.dependency-depth"))
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps.required {
+			if !queued[dep.ID] {
+				queued[dep.ID] = true
+				selected = append(selected, dep)
+				fmt.Fprintf(core.Output, "Dependency \"%s\" of \"%s\" will also be installed\n", dep.Name, v.Name)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// verifyFileDownload checks that a file can be downloaded, that it matches the hash given by the API, and that its
+// length matches the length given by the API. If the trust-api-hash option is set, only the length is checked, using
+// the headers of the file if possible (if the server doesn't give the length, the file is downloaded to check it).
+func verifyFileDownload(ctx context.Context, fileInfoData modFileInfo) error {
+	if fileInfoData.DownloadURL == "" {
+		// createModFile reports files that can't be downloaded
+		return nil
+	}
+	u, err := core.ReencodeURL(fileInfoData.DownloadURL)
+	if err != nil {
+		return err
+	}
+
+	trustAPIHash := viper.GetBool("curseforge.install.trust-api-hash")
+	if trustAPIHash {
+		req, err := http.NewRequestWithContext(ctx, "HEAD", u, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", fileInfoData.FileName, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to verify %s: invalid status code %d", fileInfoData.FileName, resp.StatusCode)
+		}
+		if resp.ContentLength >= 0 {
+			if resp.ContentLength != int64(fileInfoData.Length) {
+				return fmt.Errorf("length of %s (%d bytes) doesn't match the expected length (%d bytes)", fileInfoData.FileName, resp.ContentLength, fileInfoData.Length)
+			}
+			return nil
+		}
+	}
+
+	fmt.Fprintf(core.Output, "Verifying %s...\n", fileInfoData.FileName)
+	var counter lengthCounter
+	if trustAPIHash {
+		err = downloadUnchecked(ctx, u, &counter)
+	} else {
+		hash, hashFormat, ok := fileInfoData.getBestHash()
+		if !ok {
+			return errors.New("no valid hash available for file " + fileInfoData.FileName)
+		}
+		modData := core.Mod{Download: core.ModDownload{URL: u, HashFormat: hashFormat, Hash: hash}}
+		err = modData.DownloadFile(&counter)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", fileInfoData.FileName, err)
+	}
+	if counter.length != int64(fileInfoData.Length) {
+		return fmt.Errorf("length of %s (%d bytes) doesn't match the expected length (%d bytes)", fileInfoData.FileName, counter.length, fileInfoData.Length)
+	}
+	return nil
+}
+
+// downloadUnchecked downloads a file to dest without checking its hash
+func downloadUnchecked(ctx context.Context, u string, dest io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid status code %d", resp.StatusCode)
+	}
+	_, err = io.Copy(dest, resp.Body)
+	return err
+}
+
+// lengthCounter discards data written to it, counting its length
+type lengthCounter struct {
+	length int64
+}
+
+func (c *lengthCounter) Write(p []byte) (int, error) {
+	c.length += int64(len(p))
+	return len(p), nil
+}
+
+// parseSinceDate parses the date given to --since, as a date (e.g. 2021-06-01) or a date and time in the formats used
+// by CurseForge
+func parseSinceDate(str string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339Nano, cfDateFormatString} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %s (expected a date such as 2021-06-01)", str)
+}
+
+// adoptedFileToRemove returns the absolute path of the mod file adopted with --fingerprint if --remove-file is set,
+// removing it from the index so it is replaced by the metadata file; otherwise, the file is kept and "" is returned
+func adoptedFileToRemove(index *core.Index, fingerprintPath string) (string, error) {
+	if fingerprintPath == "" || !viper.GetBool("curseforge.install.remove-file") {
+		return "", nil
+	}
+	absPath, err := filepath.Abs(fingerprintPath)
+	if err != nil {
+		return "", err
+	}
+	return absPath, index.RemoveFile(absPath)
+}
+
+// getIDsFromFingerprint finds the mod and file IDs of a mod file, by looking up its fingerprint
+func getIDsFromFingerprint(ctx context.Context, path string) (int, int, error) {
+	hash, err := murmur2FingerprintFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	res, err := getFingerprintInfoWithRetry(ctx, []int{hash})
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(res.ExactMatches) == 0 {
+		return 0, 0, fmt.Errorf("%s (%d) was not found on CurseForge", path, hash)
+	}
+	return res.ExactMatches[0].ID, res.ExactMatches[0].File.ID, nil
+}
+
+// inferGameVersionFromJar finds the newest Minecraft version that a mod file supports, by looking up its fingerprint
+func inferGameVersionFromJar(ctx context.Context, path string) (string, error) {
+	hash, err := murmur2FingerprintFile(path)
+	if err != nil {
+		return "", err
+	}
+	res, err := getFingerprintInfoWithRetry(ctx, []int{hash})
+	if err != nil {
+		return "", err
+	}
+	if len(res.ExactMatches) == 0 {
+		return "", fmt.Errorf("%s was not found on CurseForge", path)
+	}
+
+	var best string
+	for _, v := range res.ExactMatches[0].File.getMCVersions() {
+		// Snapshots and other non-release versions are ignored
+		if mcVersionRegex.MatchString(v) && (best == "" || compareMCVersions(v, best) > 0) {
+			best = v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no Minecraft version found for %s", path)
+	}
+	return best, nil
+}
+
+// compareMCVersions compares two release version numbers (e.g. 1.16.5), returning a positive number if a is newer
+func compareMCVersions(a string, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			return aNum - bNum
+		}
+	}
+	return 0
+}
+
 var addonIDFlag int
 var fileIDFlag int
 
@@ -379,4 +858,34 @@ func init() {
 
 	installCmd.Flags().IntVar(&addonIDFlag, "addon-id", 0, "The curseforge addon ID to use")
 	installCmd.Flags().IntVar(&fileIDFlag, "file-id", 0, "The curseforge file ID to use")
+	installCmd.Flags().String("release-channel", "", "The least stable release channel (release, beta or alpha) to accept files from for this mod (defaults to the curseforge.release-channel option)")
+	installCmd.Flags().Int("max-results", 10, "The maximum number of search results to display at once")
+	_ = viper.BindPFlag("curseforge.install.max-results", installCmd.Flags().Lookup("max-results"))
+	installCmd.Flags().Int("dependency-depth", 0, "The maximum depth of dependencies to resolve (1 for only direct dependencies; 0 for unlimited)")
+	_ = viper.BindPFlag("curseforge.install.dependency-depth", installCmd.Flags().Lookup("dependency-depth"))
+	installCmd.Flags().Bool("accept-license", false, "Install mods that don't allow redistribution without asking for confirmation")
+	_ = viper.BindPFlag("curseforge.install.accept-license", installCmd.Flags().Lookup("accept-license"))
+	installCmd.Flags().String("game-version-from-jar", "", "If the pack has no Minecraft version set, use the newest version supported by this mod file (found by its fingerprint)")
+	_ = viper.BindPFlag("curseforge.install.game-version-from-jar", installCmd.Flags().Lookup("game-version-from-jar"))
+	installCmd.Flags().Bool("no-optional-prompt", false, "Don't ask whether to install optional dependencies; they are listed but not installed (the default when input is not a terminal; can also be set in the config)")
+	_ = viper.BindPFlag("curseforge.install.no-optional-prompt", installCmd.Flags().Lookup("no-optional-prompt"))
+	installCmd.Flags().String("side", "", "The side to add mods with (client, server or both; defaults to the side the file is tagged with, or the default-side option)")
+	_ = viper.BindPFlag("curseforge.install.side", installCmd.Flags().Lookup("side"))
+	installCmd.Flags().Int("min-file-age", 0, "Only install files released at least this many hours ago (defaults to the curseforge.min-file-age option)")
+	_ = viper.BindPFlag("curseforge.min-file-age", installCmd.Flags().Lookup("min-file-age"))
+	installCmd.Flags().Bool("trust-api-hash", false, "Don't hash downloaded files to check them against the hashes given by CurseForge (their length is still checked)")
+	_ = viper.BindPFlag("curseforge.install.trust-api-hash", installCmd.Flags().Lookup("trust-api-hash"))
+	installCmd.Flags().String("since", "", "Warn and ask for confirmation if the latest compatible file of the mod was released before this date (e.g. 2021-06-01)")
+	_ = viper.BindPFlag("curseforge.install.since", installCmd.Flags().Lookup("since"))
+	installCmd.Flags().String("fingerprint", "", "Install the mod matching this mod file (found by its fingerprint) as a metadata file")
+	_ = viper.BindPFlag("curseforge.install.fingerprint", installCmd.Flags().Lookup("fingerprint"))
+	installCmd.Flags().Bool("remove-file", false, "Delete the mod file given with --fingerprint once it has been replaced by a metadata file")
+	_ = viper.BindPFlag("curseforge.install.remove-file", installCmd.Flags().Lookup("remove-file"))
+	installCmd.Flags().StringSlice("accept-game-versions", nil, "Additional Minecraft versions to accept files for, for this install only (in addition to the acceptable-game-versions option)")
+	installCmd.Flags().Bool("pick-file", false, "Choose which file to install from the files matching the pack's Minecraft version and loader")
+	_ = viper.BindPFlag("curseforge.install.pick-file", installCmd.Flags().Lookup("pick-file"))
+	installCmd.Flags().Bool("always-prompt", false, "Always ask which mod or file to install, even if there is only one option")
+	_ = viper.BindPFlag("curseforge.install.always-prompt", installCmd.Flags().Lookup("always-prompt"))
+	installCmd.Flags().Bool("prefer-server", false, "Prefer server-compatible files over client-only files (for server packs)")
+	_ = viper.BindPFlag("curseforge.install.prefer-server", installCmd.Flags().Lookup("prefer-server"))
 }
@@ -0,0 +1,624 @@
+package curseforge
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/packwiz/packwiz/core"
+	"github.com/spf13/viper"
+)
+
+func TestVerifyFileDownload(t *testing.T) {
+	content := []byte("mod file contents")
+	sum := sha1.Sum(content)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/mod.jar":
+			_, _ = w.Write(content)
+		case "/no-length.jar":
+			// Flushing before writing the body sends it without a Content-Length header
+			w.(http.Flusher).Flush()
+			if req.Method != "HEAD" {
+				_, _ = w.Write(content)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		hash         string
+		length       int
+		trustAPIHash bool
+		wantErr      bool
+	}{
+		{"matching file", "/mod.jar", hex.EncodeToString(sum[:]), len(content), false, false},
+		{"wrong hash", "/mod.jar", "0123456789abcdef0123456789abcdef01234567", len(content), false, true},
+		{"wrong length", "/mod.jar", hex.EncodeToString(sum[:]), len(content) + 1, false, true},
+		{"missing file", "/missing.jar", hex.EncodeToString(sum[:]), len(content), false, true},
+		{"trusted hash", "/mod.jar", "0123456789abcdef0123456789abcdef01234567", len(content), true, false},
+		{"trusted hash with wrong length", "/mod.jar", hex.EncodeToString(sum[:]), len(content) + 1, true, true},
+		{"trusted hash without length header", "/no-length.jar", "0123456789abcdef0123456789abcdef01234567", len(content), true, false},
+		{"trusted hash with wrong length without length header", "/no-length.jar", hex.EncodeToString(sum[:]), len(content) + 1, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.install.trust-api-hash", tt.trustAPIHash)
+			defer viper.Set("curseforge.install.trust-api-hash", false)
+
+			var fileInfoData modFileInfo
+			err := json.Unmarshal([]byte(`{"hashes": [{"value": "`+tt.hash+`", "algorithm": 1}]}`), &fileInfoData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fileInfoData.FileName = "mod.jar"
+			fileInfoData.DownloadURL = server.URL + tt.path
+			fileInfoData.Length = tt.length
+
+			err = verifyFileDownload(context.Background(), fileInfoData)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyFileDownload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAdoptFileByFingerprint(t *testing.T) {
+	content := []byte("adopted mod file contents")
+	newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/fingerprint" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var hashes []int
+		if err := json.NewDecoder(req.Body).Decode(&hashes); err != nil {
+			t.Error(err)
+		}
+		res := addonFingerprintResponse{IsCacheBuilt: true}
+		if len(hashes) == 1 && hashes[0] == murmur2Fingerprint(content) {
+			res.ExactMatches = []addonFingerprintMatch{{ID: 1234, File: modFileInfo{ID: 5678}}}
+		}
+		writeTestJSON(t, w, res)
+	}))
+
+	dir := t.TempDir()
+	jarPath := filepath.Join(dir, "mods", "adopted.jar")
+	if err := os.MkdirAll(filepath.Dir(jarPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(jarPath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modID, fileID, err := getIDsFromFingerprint(context.Background(), jarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modID != 1234 || fileID != 5678 {
+		t.Fatalf("getIDsFromFingerprint() = %d, %d, want 1234, 5678", modID, fileID)
+	}
+
+	indexFile := filepath.Join(dir, "index.toml")
+	if err := ioutil.WriteFile(indexFile, []byte("hash-format = \"sha256\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	index, err := core.LoadIndex(indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("mods-folder", "mods")
+	defer viper.Set("mods-folder", "")
+	info := modInfo{ID: modID, Name: "Adopted Mod", Slug: "adopted-mod"}
+	file := modFileInfo{ID: fileID, FileName: "adopted.jar", DownloadURL: "https://edge.forgecdn.net/files/5/678/adopted.jar", Fingerprint: murmur2Fingerprint(content)}
+	if err := createModFile(info, file, &index, false, "", core.UniversalSide); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := core.LoadMod(filepath.Join(dir, "mods", "adopted-mod"+core.ModExtension)); err != nil {
+		t.Errorf("metadata file wasn't created: %v", err)
+	}
+	// The adopted file is only removed with --remove-file
+	if removePath, err := adoptedFileToRemove(&index, jarPath); err != nil || removePath != "" {
+		t.Errorf("adoptedFileToRemove() = %q, %v, want the file to be kept", removePath, err)
+	}
+	viper.Set("curseforge.install.remove-file", true)
+	defer viper.Set("curseforge.install.remove-file", false)
+	if removePath, err := adoptedFileToRemove(&index, jarPath); err != nil || removePath != jarPath {
+		t.Errorf("adoptedFileToRemove() = %q, %v, want %q", removePath, err, jarPath)
+	}
+	if _, err := os.Stat(jarPath); err != nil {
+		t.Errorf("adopted file was removed before the metadata was written: %v", err)
+	}
+}
+
+// writeTestJar writes a mod jar containing the given files from testdata (keyed by their path in the jar), returning
+// its path and contents
+func writeTestJar(t *testing.T, files map[string]string) (string, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, fixture := range files {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "mod.jar")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, buf.Bytes()
+}
+
+func TestInferGameVersionFromJar(t *testing.T) {
+	fabricJar, fabricData := writeTestJar(t, map[string]string{"fabric.mod.json": "fabric.mod.json"})
+	forgeJar, forgeData := writeTestJar(t, map[string]string{"META-INF/mods.toml": "mods.toml"})
+	unknownJar, _ := writeTestJar(t, map[string]string{"fabric.mod.json": "fabric.mod.json", "META-INF/mods.toml": "mods.toml"})
+
+	var fabricFile, forgeFile modFileInfo
+	// The versions of the Fabric file are untyped; the Forge file has typed versions, including a Java version
+	if err := json.Unmarshal([]byte(`{"id": 1, "gameVersion": ["1.18.1", "Fabric", "1.18.2", "22w03a", "1.18"]}`), &fabricFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{"id": 2, "sortableGameVersions": [{"gameVersionName": "1.16.4", "gameVersionTypeId": 70886},
+		{"gameVersionName": "Forge", "gameVersionTypeId": 68441}, {"gameVersionName": "Java 8", "gameVersionTypeId": 4458},
+		{"gameVersionName": "1.16.5", "gameVersionTypeId": 70886}]}`), &forgeFile); err != nil {
+		t.Fatal(err)
+	}
+	newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/fingerprint" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var hashes []int
+		if err := json.NewDecoder(req.Body).Decode(&hashes); err != nil {
+			t.Error(err)
+		}
+		res := addonFingerprintResponse{IsCacheBuilt: true}
+		if len(hashes) == 1 {
+			switch hashes[0] {
+			case murmur2Fingerprint(fabricData):
+				res.ExactMatches = []addonFingerprintMatch{{ID: 1234, File: fabricFile}}
+			case murmur2Fingerprint(forgeData):
+				res.ExactMatches = []addonFingerprintMatch{{ID: 1234, File: forgeFile}}
+			}
+		}
+		writeTestJSON(t, w, res)
+	}))
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "fabric", path: fabricJar, want: "1.18.2"},
+		{name: "forge", path: forgeJar, want: "1.16.5"},
+		{name: "not found", path: unknownJar, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inferGameVersionFromJar(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("inferGameVersionFromJar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("inferGameVersionFromJar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// setTestInput uses the given text as standard input for the rest of the test, returning a buffer that prompts are
+// written to
+func setTestInput(t *testing.T, input string) *bytes.Buffer {
+	t.Helper()
+	inputFile := filepath.Join(t.TempDir(), "input")
+	if err := ioutil.WriteFile(inputFile, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(inputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prompts bytes.Buffer
+	oldStdin, oldPromptOutput := os.Stdin, core.PromptOutput
+	os.Stdin, core.PromptOutput = f, &prompts
+	t.Cleanup(func() {
+		os.Stdin, core.PromptOutput = oldStdin, oldPromptOutput
+		_ = f.Close()
+	})
+	return &prompts
+}
+
+func TestAcceptGameVersions(t *testing.T) {
+	defer viper.Set("acceptable-game-versions", nil)
+	info := modInfo{ID: 1, LatestFiles: []modFileInfo{
+		{ID: 10, FileName: "mod-1.20.1.jar", FileType: fileTypeRelease, GameVersions: []string{"1.20.1", "Fabric"}},
+		{ID: 11, FileName: "mod-1.19.2.jar", FileType: fileTypeRelease, GameVersions: []string{"1.19.2", "Fabric"}},
+	}}
+
+	if _, err := info.findBestFile("1.20.4", modloaderTypeFabric, fileTypeRelease); !errors.Is(err, errNoFileAvailable) {
+		t.Fatalf("findBestFile() error = %v, want %v", err, errNoFileAvailable)
+	}
+
+	viper.Set("acceptable-game-versions", []string{"1.20.2"})
+	acceptGameVersions([]string{"1.20.1"})
+	if got, want := viper.GetStringSlice("acceptable-game-versions"), []string{"1.20.2", "1.20.1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("acceptable-game-versions = %v, want %v", got, want)
+	}
+	file, err := info.findBestFile("1.20.4", modloaderTypeFabric, fileTypeRelease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.ID != 10 {
+		t.Errorf("findBestFile() = file %d, want the file for the accepted version (10)", file.ID)
+	}
+}
+
+func TestConfirmDistribution(t *testing.T) {
+	allowed, disallowed := true, false
+	defer viper.Set("curseforge.install.accept-license", false)
+
+	tests := []struct {
+		name          string
+		allow         *bool
+		acceptLicense bool
+		input         string
+		want          bool
+		wantPrompt    bool
+	}{
+		{name: "unknown", allow: nil, want: true},
+		{name: "allowed", allow: &allowed, want: true},
+		{name: "not allowed with accept-license", allow: &disallowed, acceptLicense: true, want: true},
+		{name: "not allowed refused", allow: &disallowed, input: "n\n", wantPrompt: true},
+		{name: "not allowed by default", allow: &disallowed, input: "\n", want: true, wantPrompt: true},
+		{name: "not allowed accepted", allow: &disallowed, input: "y\n", want: true, wantPrompt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.install.accept-license", tt.acceptLicense)
+			prompts := setTestInput(t, tt.input)
+			modInfoData := modInfo{Name: "Test Mod", WebsiteURL: "https://www.curseforge.com/minecraft/mc-mods/test-mod", AllowModDistribution: tt.allow}
+			if got := confirmDistribution(modInfoData); got != tt.want {
+				t.Errorf("confirmDistribution() = %v, want %v", got, tt.want)
+			}
+			if (prompts.Len() > 0) != tt.wantPrompt {
+				t.Errorf("confirmDistribution() prompted %q, want prompt: %v", prompts.String(), tt.wantPrompt)
+			}
+		})
+	}
+}
+
+func TestConfirmFileDate(t *testing.T) {
+	since := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	staleFile := modFileInfo{FileName: "stale.jar", Date: cfDateFormat{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	freshFile := modFileInfo{FileName: "fresh.jar", Date: cfDateFormat{time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)}}
+
+	tests := []struct {
+		name       string
+		file       modFileInfo
+		input      string
+		want       bool
+		wantPrompt bool
+	}{
+		{name: "fresh file", file: freshFile, want: true},
+		{name: "stale file refused", file: staleFile, input: "n\n", wantPrompt: true},
+		{name: "stale file by default", file: staleFile, input: "\n", wantPrompt: true},
+		{name: "stale file at end of input", file: staleFile, wantPrompt: true},
+		{name: "stale file accepted", file: staleFile, input: "y\n", want: true, wantPrompt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompts := setTestInput(t, tt.input)
+			if got := confirmFileDate(modInfo{Name: "Test Mod"}, tt.file, since); got != tt.want {
+				t.Errorf("confirmFileDate() = %v, want %v", got, tt.want)
+			}
+			if (prompts.Len() > 0) != tt.wantPrompt {
+				t.Errorf("confirmFileDate() prompted %q, want prompt: %v", prompts.String(), tt.wantPrompt)
+			}
+		})
+	}
+}
+
+func TestSearchMaxResults(t *testing.T) {
+	var mods []modInfo
+	for i := 1; i <= 25; i++ {
+		mods = append(mods, modInfo{ID: i, Name: "Test Mod " + strconv.Itoa(i)})
+	}
+	newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/addon/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		index, _ := strconv.Atoi(req.URL.Query().Get("index"))
+		pageSize, _ := strconv.Atoi(req.URL.Query().Get("pageSize"))
+		end := index + pageSize
+		if end > len(mods) {
+			end = len(mods)
+		}
+		writeTestJSON(t, w, mods[index:end])
+	}))
+	optionRegex := regexp.MustCompile(`(?m)^\d+\) (.*)$`)
+
+	tests := []struct {
+		name        string
+		maxResults  int
+		wantResults int
+		wantMore    bool
+	}{
+		{name: "limited", maxResults: 5, wantResults: 5, wantMore: true},
+		{name: "default", maxResults: 10, wantResults: 10, wantMore: true},
+		{name: "more than available", maxResults: 30, wantResults: 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.install.max-results", tt.maxResults)
+			defer viper.Set("curseforge.install.max-results", 0)
+			prompts := setTestInput(t, "1\n")
+
+			cancelled, _, err := searchCurseforgeInternal(context.Background(), []string{"Test", "Mod"}, "1.18.2", modloaderTypeFabric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cancelled {
+				t.Fatal("searchCurseforgeInternal() was cancelled")
+			}
+
+			results := 0
+			more := false
+			for _, match := range optionRegex.FindAllStringSubmatch(prompts.String(), -1) {
+				switch match[1] {
+				case "Cancel":
+				case "Show more results":
+					more = true
+				default:
+					results++
+				}
+			}
+			if results != tt.wantResults || more != tt.wantMore {
+				t.Errorf("showed %d results (more results: %v), want %d (more results: %v)", results, more, tt.wantResults, tt.wantMore)
+			}
+		})
+	}
+}
+
+func TestPickFile(t *testing.T) {
+	fabricFile := modFileInfo{ID: 10, FileName: "mod-fabric.jar", FileType: fileTypeRelease, GameVersions: []string{"1.18.2", "Fabric"}}
+	forgeFile := modFileInfo{ID: 11, FileName: "mod-forge.jar", FileType: fileTypeRelease, GameVersions: []string{"1.18.2", "Forge"}}
+	olderFabricFile := modFileInfo{ID: 9, FileName: "mod-fabric-old.jar", FileType: fileTypeRelease, GameVersions: []string{"1.18.2", "Fabric"}}
+	defer viper.Set("curseforge.install.always-prompt", false)
+
+	tests := []struct {
+		name         string
+		files        []modFileInfo
+		alwaysPrompt bool
+		input        string
+		wantID       int
+		wantPrompt   bool
+	}{
+		{name: "single match", files: []modFileInfo{fabricFile, forgeFile}, wantID: 10},
+		{name: "single match with always-prompt", files: []modFileInfo{fabricFile, forgeFile}, alwaysPrompt: true, input: "1\n", wantID: 10, wantPrompt: true},
+		{name: "multiple matches", files: []modFileInfo{fabricFile, forgeFile, olderFabricFile}, input: "2\n", wantID: 9, wantPrompt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.install.always-prompt", tt.alwaysPrompt)
+			prompts := setTestInput(t, tt.input)
+			cancelled, file, err := pickFile(modInfo{ID: 1, LatestFiles: tt.files}, "1.18.2", modloaderTypeFabric, fileTypeRelease)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cancelled {
+				t.Fatal("pickFile() was cancelled")
+			}
+			if file.ID != tt.wantID {
+				t.Errorf("pickFile() = file %d, want %d", file.ID, tt.wantID)
+			}
+			if (prompts.Len() > 0) != tt.wantPrompt {
+				t.Errorf("pickFile() prompted %q, want prompt: %v", prompts.String(), tt.wantPrompt)
+			}
+		})
+	}
+
+	if _, _, err := pickFile(modInfo{ID: 1, LatestFiles: []modFileInfo{forgeFile}}, "1.18.2", modloaderTypeFabric, fileTypeRelease); !errors.Is(err, errNoFileAvailable) {
+		t.Errorf("pickFile() error = %v, want %v", err, errNoFileAvailable)
+	}
+}
+
+func TestSearchSingleResult(t *testing.T) {
+	newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeTestJSON(t, w, []modInfo{{ID: 1, Name: "Only Mod"}})
+	}))
+	viper.Set("curseforge.install.max-results", 10)
+	defer viper.Set("curseforge.install.max-results", 0)
+	prompts := setTestInput(t, "")
+
+	cancelled, mod, err := searchCurseforgeInternal(context.Background(), []string{"Only", "Mod"}, "1.18.2", modloaderTypeFabric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled || mod.ID != 1 {
+		t.Errorf("searchCurseforgeInternal() = %v, mod %d, want mod 1", cancelled, mod.ID)
+	}
+	if prompts.Len() > 0 {
+		t.Errorf("searchCurseforgeInternal() prompted %q for a single result", prompts.String())
+	}
+}
+
+// fallbackTestSource finds a single mod, recording whether it was installed
+type fallbackTestSource struct {
+	installed *bool
+}
+
+func (s fallbackTestSource) FindMod(ctx context.Context, query string, pack core.Pack) (core.FoundMod, bool, error) {
+	if query != "Test Mod" {
+		return core.FoundMod{}, false, nil
+	}
+	return core.FoundMod{
+		Name: "Test Mod",
+		URL:  "https://example.com/test-mod",
+		Install: func() error {
+			*s.installed = true
+			return nil
+		},
+	}, true, nil
+}
+
+func TestInstallFromOtherSource(t *testing.T) {
+	newTestAPI(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	viper.Set("curseforge.install.max-results", 10)
+	defer viper.Set("curseforge.install.max-results", 0)
+	var installed bool
+	core.ModSources["fallbacktest"] = fallbackTestSource{installed: &installed}
+	defer delete(core.ModSources, "fallbacktest")
+
+	tests := []struct {
+		name          string
+		query         string
+		input         string
+		wantInstalled bool
+	}{
+		{name: "accepted", query: "Test Mod", input: "y\n", wantInstalled: true},
+		{name: "refused", query: "Test Mod", input: "n\n"},
+		{name: "not found", query: "Other Mod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installed = false
+			prompts := setTestInput(t, tt.input)
+			_, _, cfErr := searchCurseforgeInternal(context.Background(), strings.Fields(tt.query), "1.18.2", modloaderTypeFabric)
+			if cfErr == nil {
+				t.Fatal("searchCurseforgeInternal() returned no error while CurseForge is down")
+			}
+
+			if got := installFromOtherSource(context.Background(), tt.query, core.Pack{}, cfErr); got != tt.wantInstalled || installed != tt.wantInstalled {
+				t.Errorf("installFromOtherSource() = %v (installed: %v), want %v", got, installed, tt.wantInstalled)
+			}
+			if wantPrompt := tt.input != ""; strings.Contains(prompts.String(), "from fallbacktest instead?") != wantPrompt {
+				t.Errorf("installFromOtherSource() prompted %q, want prompt: %v", prompts.String(), wantPrompt)
+			}
+		})
+	}
+}
+
+func TestSelectOptionalDependencies(t *testing.T) {
+	defer viper.Set("curseforge.install.no-optional-prompt", false)
+	defer func() { isInteractive = core.IsInteractive }()
+	oldOutput := core.Output
+	defer func() { core.Output = oldOutput }()
+	optional := []modInfo{{ID: 2, Name: "Optional Mod"}}
+
+	tests := []struct {
+		name        string
+		noPrompt    bool
+		interactive bool
+		wantPrompt  bool
+		wantListed  bool
+	}{
+		{name: "no-optional-prompt", noPrompt: true, interactive: true, wantListed: true},
+		{name: "not interactive", interactive: false, wantListed: true},
+		{name: "interactive", interactive: true, wantPrompt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("curseforge.install.no-optional-prompt", tt.noPrompt)
+			isInteractive = func() bool { return tt.interactive }
+			var output bytes.Buffer
+			core.Output = &output
+			// The dependency is accepted if the user is asked, but is already queued, so it isn't looked up
+			prompts := setTestInput(t, "y\n")
+
+			selected, err := selectOptionalDependencies(context.Background(), optional, map[int]bool{1: true, 2: true}, core.Index{}, "1.18.2", modloaderTypeFabric, fileTypeRelease)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(selected) > 0 {
+				t.Errorf("selectOptionalDependencies() = %v, want no dependencies", selected)
+			}
+			if (prompts.Len() > 0) != tt.wantPrompt {
+				t.Errorf("selectOptionalDependencies() prompted %q, want prompt: %v", prompts.String(), tt.wantPrompt)
+			}
+			if strings.Contains(output.String(), "Optional Mod") != tt.wantListed {
+				t.Errorf("selectOptionalDependencies() printed %q, want optional dependencies listed: %v", output.String(), tt.wantListed)
+			}
+		})
+	}
+}
+
+func TestCompareMCVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.16.5", b: "1.16.5", want: 0},
+		{a: "1.16", b: "1.16.0", want: 0},
+		{a: "1.17", b: "1.16.5", want: 1},
+		{a: "1.16.5", b: "1.16.10", want: -1},
+		{a: "1.18.2", b: "1.9", want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" vs "+tt.b, func(t *testing.T) {
+			got := compareMCVersions(tt.a, tt.b)
+			if (got > 0) != (tt.want > 0) || (got < 0) != (tt.want < 0) {
+				t.Errorf("compareMCVersions() = %d, want sign of %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSinceDate(t *testing.T) {
+	tests := []struct {
+		str     string
+		want    time.Time
+		wantErr bool
+	}{
+		{str: "2021-06-01", want: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{str: "2021-06-01T12:30:00Z", want: time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)},
+		{str: "2021-06-01T12:30:00.5", want: time.Date(2021, 6, 1, 12, 30, 0, 500000000, time.UTC)},
+		{str: "01/06/2021", wantErr: true},
+		{str: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, err := parseSinceDate(tt.str)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSinceDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSinceDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,44 +19,44 @@ var openCmd = &cobra.Command{
 	Args:    cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args[0]) == 0 {
-			fmt.Println("You must specify a mod.")
+			fmt.Fprintln(os.Stderr, "You must specify a mod.")
 			os.Exit(1)
 		}
 
-		fmt.Println("Loading modpack...")
+		fmt.Fprintln(core.Output, "Loading modpack...")
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		resolvedMod, ok := index.FindMod(args[0])
 		if !ok {
 			// TODO: should this auto-refresh???????
-			fmt.Println("You don't have this mod installed.")
+			fmt.Fprintln(os.Stderr, "You don't have this mod installed.")
 			os.Exit(1)
 		}
 		modData, err := core.LoadMod(resolvedMod)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		updateData, ok := modData.GetParsedUpdateData("curseforge")
 		if !ok {
-			fmt.Println("This mod doesn't seem to be a curseforge mod!")
+			fmt.Fprintln(os.Stderr, "This mod doesn't seem to be a curseforge mod!")
 			os.Exit(1)
 		}
 		cfUpdateData := updateData.(cfUpdateData)
-		fmt.Println("Opening browser...")
+		fmt.Fprintln(core.Output, "Opening browser...")
 		url := "https://minecraft.curseforge.com/projects/" + strconv.Itoa(cfUpdateData.ProjectID)
 		err = open.Start(url)
 		if err != nil {
-			fmt.Println("Opening page failed, direct link:")
-			fmt.Println(url)
+			fmt.Fprintln(os.Stderr, "Opening page failed, direct link:")
+			fmt.Fprintln(os.Stderr, url)
 		}
 	},
 }
@@ -15,14 +15,14 @@ func ReadMetadata(s ImportPackSource) ImportPackMetadata {
 	metaFile := s.GetPackFile()
 	rdr, err := metaFile.Open()
 	if err != nil {
-		fmt.Printf("Error reading file: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading file: %s\n", err)
 		os.Exit(1)
 	}
 
 	// Read the whole file (as we are going to parse it multiple times)
 	fileData, err := ioutil.ReadAll(rdr)
 	if err != nil {
-		fmt.Printf("Error reading file: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading file: %s\n", err)
 		os.Exit(1)
 	}
 
@@ -30,7 +30,7 @@ func ReadMetadata(s ImportPackSource) ImportPackMetadata {
 	var jsonFile map[string]interface{}
 	err = json.Unmarshal(fileData, &jsonFile)
 	if err != nil {
-		fmt.Printf("Error parsing JSON: %s\n", err)
+		fmt.Fprintf(os.Stderr, "Error parsing JSON: %s\n", err)
 		os.Exit(1)
 	}
 
@@ -42,7 +42,7 @@ func ReadMetadata(s ImportPackSource) ImportPackMetadata {
 		packMeta := cursePackMeta{importSrc: s}
 		err = json.Unmarshal(fileData, &packMeta)
 		if err != nil {
-			fmt.Printf("Error parsing JSON: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error parsing JSON: %s\n", err)
 			os.Exit(1)
 		}
 		packImport = packMeta
@@ -52,7 +52,7 @@ func ReadMetadata(s ImportPackSource) ImportPackMetadata {
 		packMeta := twitchInstalledPackMeta{importSrc: s}
 		err = json.Unmarshal(fileData, &packMeta)
 		if err != nil {
-			fmt.Printf("Error parsing JSON: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error parsing JSON: %s\n", err)
 			os.Exit(1)
 		}
 		packImport = packMeta
@@ -67,9 +67,44 @@ type AddonFileReference struct {
 	FileID    int
 	// OptionalDisabled is true if the file is optional and disabled (turned off in Twitch launcher)
 	OptionalDisabled bool
+	// FileName, HashFormat and Hash are only written to the hashes sidecar file
+	FileName   string
+	HashFormat string
+	Hash       string
 }
 
-func WriteManifestFromPack(pack core.Pack, fileRefs []AddonFileReference, projectID int, out io.Writer) error {
+// ManifestHashesFile is the name of the sidecar file written alongside manifest.json, listing the hash of each file
+const ManifestHashesFile = "manifest-hashes.json"
+
+// WriteManifestHashes writes a sidecar file for the manifest listing the hash of each referenced file, so they can be
+// verified without querying CurseForge
+func WriteManifestHashes(fileRefs []AddonFileReference, out io.Writer) error {
+	type fileHash struct {
+		ProjectID  int    `json:"projectID"`
+		FileID     int    `json:"fileID"`
+		FileName   string `json:"fileName"`
+		HashFormat string `json:"hashFormat"`
+		Hash       string `json:"hash"`
+	}
+	files := make([]fileHash, len(fileRefs))
+	for i, fr := range fileRefs {
+		files[i] = fileHash{
+			ProjectID:  fr.ProjectID,
+			FileID:     fr.FileID,
+			FileName:   fr.FileName,
+			HashFormat: fr.HashFormat,
+			Hash:       fr.Hash,
+		}
+	}
+
+	w := json.NewEncoder(out)
+	w.SetIndent("", "  ")
+	return w.Encode(struct {
+		Files []fileHash `json:"files"`
+	}{files})
+}
+
+func WriteManifestFromPack(pack core.Pack, fileRefs []AddonFileReference, projectID int, overridesDir string, out io.Writer) error {
 	files := make([]struct {
 		ProjectID int  `json:"projectID"`
 		FileID    int  `json:"fileID"`
@@ -111,7 +146,7 @@ func WriteManifestFromPack(pack core.Pack, fileRefs []AddonFileReference, projec
 		Author:          pack.Author,
 		ProjectID:       projectID,
 		Files:           files,
-		Overrides:       "overrides",
+		Overrides:       overridesDir,
 	}
 
 	w := json.NewEncoder(out)
@@ -0,0 +1,74 @@
+package packinterop
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+func TestWriteManifestHashes(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileRefs []AddonFileReference
+		want     string
+	}{
+		{
+			name: "no files",
+			want: "{\n  \"files\": []\n}\n",
+		},
+		{
+			name: "files",
+			fileRefs: []AddonFileReference{
+				{ProjectID: 1, FileID: 2, OptionalDisabled: true, FileName: "a.jar", HashFormat: "sha1", Hash: "abc"},
+				{ProjectID: 3, FileID: 4, FileName: "b.jar", HashFormat: "murmur2", Hash: "1234"},
+			},
+			want: `{
+  "files": [
+    {
+      "projectID": 1,
+      "fileID": 2,
+      "fileName": "a.jar",
+      "hashFormat": "sha1",
+      "hash": "abc"
+    },
+    {
+      "projectID": 3,
+      "fileID": 4,
+      "fileName": "b.jar",
+      "hashFormat": "murmur2",
+      "hash": "1234"
+    }
+  ]
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteManifestHashes(tt.fileRefs, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("WriteManifestHashes() =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteManifestFromPackOmitsHashes(t *testing.T) {
+	pack := core.Pack{Name: "Test Pack", Versions: map[string]string{"minecraft": "1.18.2", "fabric": "0.13.3"}}
+	fileRefs := []AddonFileReference{{ProjectID: 1, FileID: 2, FileName: "a.jar", HashFormat: "sha1", Hash: "abc"}}
+
+	var buf bytes.Buffer
+	if err := WriteManifestFromPack(pack, fileRefs, 0, "overrides", &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"a.jar", "sha1", "abc"} {
+		if strings.Contains(buf.String(), s) {
+			t.Errorf("manifest contains %q, which should only be in %s:\n%s", s, ManifestHashesFile, buf.String())
+		}
+	}
+}
@@ -2,15 +2,21 @@ package curseforge
 
 import (
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/packwiz/packwiz/core"
+	"github.com/spf13/viper"
 )
 
 // addonSlugRequest is sent to the CurseProxy GraphQL api to get the id from a slug
@@ -39,7 +45,7 @@ type addonSlugResponse struct {
 
 // Most of this is shamelessly copied from my previous attempt at modpack management:
 // https://github.com/comp500/modpack-editor/blob/master/query.go
-func modIDFromSlug(slug string) (int, error) {
+func modIDFromSlug(ctx context.Context, slug string) (int, error) {
 	request := addonSlugRequest{
 		Query: `
 		query getIDFromSlug($slug: String) {
@@ -55,7 +61,7 @@ func modIDFromSlug(slug string) (int, error) {
 	}
 	request.Variables.Slug = slug
 
-	// Uses the curse.nikky.moe GraphQL api
+	// Uses the curse.nikky.moe GraphQL api (by default)
 	var response addonSlugResponse
 	client := &http.Client{}
 
@@ -64,20 +70,16 @@ func modIDFromSlug(slug string) (int, error) {
 		return 0, err
 	}
 
-	req, err := http.NewRequest("POST", "https://curse.nikky.moe/graphql", bytes.NewBuffer(requestBytes))
+	req, err := newRequest(ctx, "POST", getSlugAPIURL(), bytes.NewBuffer(requestBytes))
 	if err != nil {
 		return 0, err
 	}
 
-	// TODO: make this configurable application-wide
-	req.Header.Set("User-Agent", "packwiz/packwiz client")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(client, req)
 	if err != nil {
 		return 0, err
 	}
+	defer resp.Body.Close()
 
 	err = json.NewDecoder(resp.Body).Decode(&response)
 	if err != nil && err != io.EOF {
@@ -85,7 +87,11 @@ func modIDFromSlug(slug string) (int, error) {
 	}
 
 	if len(response.Exception) > 0 || len(response.Message) > 0 {
-		return 0, fmt.Errorf("error requesting id for slug: %s", response.Message)
+		return 0, &CurseForgeAPIError{
+			StatusCode: resp.StatusCode,
+			Message:    response.Message,
+			Exception:  response.Exception,
+		}
 	}
 
 	for _, addonData := range response.Data.Addons {
@@ -94,7 +100,7 @@ func modIDFromSlug(slug string) (int, error) {
 			return addonData.ID, nil
 		}
 	}
-	return 0, errors.New("addon not found")
+	return 0, fmt.Errorf("%w: %s", ErrModNotFound, slug)
 }
 
 //noinspection GoUnusedConst
@@ -122,6 +128,8 @@ const (
 	modloaderTypeCauldron
 	modloaderTypeLiteloader
 	modloaderTypeFabric
+	modloaderTypeQuilt
+	modloaderTypeNeoForge
 )
 
 //noinspection GoUnusedConst
@@ -147,27 +155,33 @@ type modInfo struct {
 		Modloader   int    `json:"modLoader"`
 	} `json:"gameVersionLatestFiles"`
 	ModLoaders []string `json:"modLoaders"`
+	// AllowModDistribution is false if the author doesn't allow the mod to be redistributed; nil if unknown
+	AllowModDistribution *bool `json:"allowModDistribution"`
 }
 
-func getModInfo(modID int) (modInfo, error) {
+func getModInfo(ctx context.Context, modID int) (modInfo, error) {
 	var infoRes modInfo
 	client := &http.Client{}
 
 	idStr := strconv.Itoa(modID)
+	cacheKey := "addon/" + idStr
+	if cacheGet(cacheKey, &infoRes) {
+		return infoRes, nil
+	}
 
-	req, err := http.NewRequest("GET", "https://addons-ecs.forgesvc.net/api/v2/addon/"+idStr, nil)
+	req, err := newAPIRequest(ctx, "GET", "addon/"+idStr, nil)
 	if err != nil {
 		return modInfo{}, err
 	}
 
-	// TODO: make this configurable application-wide
-	req.Header.Set("User-Agent", "packwiz/packwiz client")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(client, req)
 	if err != nil {
 		return modInfo{}, err
 	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return modInfo{}, err
+	}
 
 	err = json.NewDecoder(resp.Body).Decode(&infoRes)
 	if err != nil && err != io.EOF {
@@ -175,13 +189,47 @@ func getModInfo(modID int) (modInfo, error) {
 	}
 
 	if infoRes.ID != modID {
-		return modInfo{}, fmt.Errorf("unexpected addon ID in CurseForge response: %d/%d", modID, infoRes.ID)
+		return modInfo{}, fmt.Errorf("%w: unexpected addon ID %d/%d", ErrUnexpectedResponse, modID, infoRes.ID)
 	}
 
+	cacheSet(cacheKey, infoRes)
 	return infoRes, nil
 }
 
-func getModInfoMultiple(modIDs []int) ([]modInfo, error) {
+func getModInfoMultiple(ctx context.Context, modIDs []int) ([]modInfo, error) {
+	// Only request mods that aren't already cached
+	var cachedRes []modInfo
+	var uncachedIDs []int
+	for _, id := range modIDs {
+		var cached modInfo
+		if cacheGet("addon/"+strconv.Itoa(id), &cached) {
+			cachedRes = append(cachedRes, cached)
+		} else {
+			uncachedIDs = append(uncachedIDs, id)
+		}
+	}
+	if len(uncachedIDs) == 0 {
+		return cachedRes, nil
+	}
+
+	chunks := chunkIDs(uncachedIDs)
+	chunkRes := make([][]modInfo, len(chunks))
+	err := runChunked(ctx, len(chunks), func(ctx context.Context, i int) error {
+		var err error
+		chunkRes[i], err = getModInfoChunk(ctx, chunks[i])
+		return err
+	})
+	if err != nil {
+		return []modInfo{}, err
+	}
+
+	for _, res := range chunkRes {
+		cachedRes = append(cachedRes, res...)
+	}
+	return cachedRes, nil
+}
+
+func getModInfoChunk(ctx context.Context, modIDs []int) ([]modInfo, error) {
 	var infoRes []modInfo
 	client := &http.Client{}
 
@@ -190,26 +238,28 @@ func getModInfoMultiple(modIDs []int) ([]modInfo, error) {
 		return []modInfo{}, err
 	}
 
-	req, err := http.NewRequest("POST", "https://addons-ecs.forgesvc.net/api/v2/addon/", bytes.NewBuffer(modIDsData))
+	req, err := newAPIRequest(ctx, "POST", "addon/", bytes.NewBuffer(modIDsData))
 	if err != nil {
 		return []modInfo{}, err
 	}
 
-	// TODO: make this configurable application-wide
-	req.Header.Set("User-Agent", "packwiz/packwiz client")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(client, req)
 	if err != nil {
 		return []modInfo{}, err
 	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return []modInfo{}, err
+	}
 
 	err = json.NewDecoder(resp.Body).Decode(&infoRes)
 	if err != nil && err != io.EOF {
 		return []modInfo{}, err
 	}
 
+	for _, v := range infoRes {
+		cacheSet("addon/"+strconv.Itoa(v.ID), v)
+	}
 	return infoRes, nil
 }
 
@@ -255,27 +305,48 @@ type modFileInfo struct {
 		Value     string `json:"value"`
 		Algorithm int    `json:"algorithm"`
 	} `json:"hashes"`
+
+	// SortableGameVersions has the same tags as GameVersions, with the type of each tag
+	SortableGameVersions []sortableGameVersion `json:"sortableGameVersions"`
 }
 
-func (i modFileInfo) getBestHash() (hash string, hashFormat string) {
-	// TODO: check if the hash is invalid (e.g. 0)
-	hash = strconv.Itoa(i.Fingerprint)
-	hashFormat = "murmur2"
+// sortableGameVersion is a version tag of a file
+type sortableGameVersion struct {
+	Name    string `json:"gameVersionName"`
+	Version string `json:"gameVersion"`
+	TypeID  int    `json:"gameVersionTypeId"`
+}
+
+// getBestHash returns the preferred hash of this file (SHA1, then MD5, then the murmur2 fingerprint); ok is false if
+// CurseForge doesn't provide any usable hash for it
+func (i modFileInfo) getBestHash() (hash string, hashFormat string, ok bool) {
 	hashPreferred := 0
 
+	// A fingerprint of 0 means CurseForge hasn't computed one, so it can't be used as a hash
+	if i.Fingerprint != 0 {
+		hash = strconv.Itoa(i.Fingerprint)
+		hashFormat = "murmur2"
+		ok = true
+	}
+
 	// Prefer SHA1, then MD5 if found:
 	if i.Hashes != nil {
 		for _, v := range i.Hashes {
+			if len(v.Value) == 0 {
+				continue
+			}
 			if v.Algorithm == hashAlgoMD5 && hashPreferred < 1 {
 				hashPreferred = 1
 
 				hash = v.Value
 				hashFormat = "md5"
+				ok = true
 			} else if v.Algorithm == hashAlgoSHA1 && hashPreferred < 2 {
 				hashPreferred = 2
 
 				hash = v.Value
 				hashFormat = "sha1"
+				ok = true
 			}
 		}
 	}
@@ -283,26 +354,30 @@ func (i modFileInfo) getBestHash() (hash string, hashFormat string) {
 	return
 }
 
-func getFileInfo(modID int, fileID int) (modFileInfo, error) {
+func getFileInfo(ctx context.Context, modID int, fileID int) (modFileInfo, error) {
 	var infoRes modFileInfo
 	client := &http.Client{}
 
 	modIDStr := strconv.Itoa(modID)
 	fileIDStr := strconv.Itoa(fileID)
+	cacheKey := "addon/" + modIDStr + "/file/" + fileIDStr
+	if cacheGet(cacheKey, &infoRes) {
+		return infoRes, nil
+	}
 
-	req, err := http.NewRequest("GET", "https://addons-ecs.forgesvc.net/api/v2/addon/"+modIDStr+"/file/"+fileIDStr, nil)
+	req, err := newAPIRequest(ctx, "GET", "addon/"+modIDStr+"/file/"+fileIDStr, nil)
 	if err != nil {
 		return modFileInfo{}, err
 	}
 
-	// TODO: make this configurable application-wide
-	req.Header.Set("User-Agent", "packwiz/packwiz client")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(client, req)
 	if err != nil {
 		return modFileInfo{}, err
 	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return modFileInfo{}, err
+	}
 
 	err = json.NewDecoder(resp.Body).Decode(&infoRes)
 	if err != nil && err != io.EOF {
@@ -310,13 +385,69 @@ func getFileInfo(modID int, fileID int) (modFileInfo, error) {
 	}
 
 	if infoRes.ID != fileID {
-		return modFileInfo{}, fmt.Errorf("unexpected file ID in CurseForge response: %d/%d", modID, infoRes.ID)
+		return modFileInfo{}, fmt.Errorf("%w: unexpected file ID %d/%d", ErrUnexpectedResponse, fileID, infoRes.ID)
 	}
 
+	cacheSet(cacheKey, infoRes)
 	return infoRes, nil
 }
 
-func getFileInfoMultiple(fileIDs []int) (map[string][]modFileInfo, error) {
+// getModFiles gets every file of a mod, including files that aren't in the LatestFiles or GameVersionLatestFiles of
+// the mod info
+func getModFiles(ctx context.Context, modID int) ([]modFileInfo, error) {
+	var infoRes []modFileInfo
+	client := &http.Client{}
+
+	cacheKey := "addon/" + strconv.Itoa(modID) + "/files"
+	if cacheGet(cacheKey, &infoRes) {
+		return infoRes, nil
+	}
+
+	req, err := newAPIRequest(ctx, "GET", cacheKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := doRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&infoRes)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	cacheSet(cacheKey, infoRes)
+	return infoRes, nil
+}
+
+func getFileInfoMultiple(ctx context.Context, fileIDs []int) (map[string][]modFileInfo, error) {
+	chunks := chunkIDs(fileIDs)
+	chunkRes := make([]map[string][]modFileInfo, len(chunks))
+	err := runChunked(ctx, len(chunks), func(ctx context.Context, i int) error {
+		var err error
+		chunkRes[i], err = getFileInfoChunk(ctx, chunks[i])
+		return err
+	})
+	if err != nil {
+		return make(map[string][]modFileInfo), err
+	}
+
+	infoRes := make(map[string][]modFileInfo)
+	for _, res := range chunkRes {
+		for k, v := range res {
+			infoRes[k] = append(infoRes[k], v...)
+		}
+	}
+	return infoRes, nil
+}
+
+func getFileInfoChunk(ctx context.Context, fileIDs []int) (map[string][]modFileInfo, error) {
 	var infoRes map[string][]modFileInfo
 	client := &http.Client{}
 
@@ -325,20 +456,19 @@ func getFileInfoMultiple(fileIDs []int) (map[string][]modFileInfo, error) {
 		return make(map[string][]modFileInfo), err
 	}
 
-	req, err := http.NewRequest("POST", "https://addons-ecs.forgesvc.net/api/v2/addon/files", bytes.NewBuffer(modIDsData))
+	req, err := newAPIRequest(ctx, "POST", "addon/files", bytes.NewBuffer(modIDsData))
 	if err != nil {
 		return make(map[string][]modFileInfo), err
 	}
 
-	// TODO: make this configurable application-wide
-	req.Header.Set("User-Agent", "packwiz/packwiz client")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(client, req)
 	if err != nil {
 		return make(map[string][]modFileInfo), err
 	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return make(map[string][]modFileInfo), err
+	}
 
 	err = json.NewDecoder(resp.Body).Decode(&infoRes)
 	if err != nil && err != io.EOF {
@@ -348,16 +478,19 @@ func getFileInfoMultiple(fileIDs []int) (map[string][]modFileInfo, error) {
 	return infoRes, nil
 }
 
-func getSearch(searchText string, gameVersion string, modloaderType int) ([]modInfo, error) {
+// getSearch returns a page of search results, starting from the given index, and whether there may be more results
+func getSearch(ctx context.Context, searchText string, gameVersion string, modloaderType int, index int, pageSize int) ([]modInfo, bool, error) {
 	var infoRes []modInfo
 	client := &http.Client{}
 
-	reqURL, err := url.Parse("https://addons-ecs.forgesvc.net/api/v2/addon/search?gameId=432&pageSize=10&categoryId=0&sectionId=6")
+	reqURL, err := url.Parse(getAPIBaseURL() + "addon/search?gameId=432&categoryId=0&sectionId=6")
 	if err != nil {
-		return []modInfo{}, err
+		return []modInfo{}, false, err
 	}
 	q := reqURL.Query()
 	q.Set("searchFilter", searchText)
+	q.Set("index", strconv.Itoa(index))
+	q.Set("pageSize", strconv.Itoa(pageSize))
 
 	if len(gameVersion) > 0 {
 		q.Set("gameVersion", gameVersion)
@@ -367,43 +500,47 @@ func getSearch(searchText string, gameVersion string, modloaderType int) ([]modI
 	}
 	reqURL.RawQuery = q.Encode()
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	req, err := newRequest(ctx, "GET", reqURL.String(), nil)
 	if err != nil {
-		return []modInfo{}, err
+		return []modInfo{}, false, err
 	}
+	setAPIToken(req)
 
-	// TODO: make this configurable application-wide
-	req.Header.Set("User-Agent", "packwiz/packwiz client")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(client, req)
 	if err != nil {
-		return []modInfo{}, err
+		return []modInfo{}, false, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return []modInfo{}, false, err
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(&infoRes)
 	if err != nil && err != io.EOF {
-		return []modInfo{}, err
+		return []modInfo{}, false, err
 	}
 
-	return infoRes, nil
+	// The API doesn't return a total count, so assume there are more results if this page is full
+	return infoRes, len(infoRes) >= pageSize, nil
 }
 
 type addonFingerprintResponse struct {
-	IsCacheBuilt bool `json:"isCacheBuilt"`
-	ExactMatches []struct {
-		ID          int           `json:"id"`
-		File        modFileInfo   `json:"file"`
-		LatestFiles []modFileInfo `json:"latestFiles"`
-	} `json:"exactMatches"`
-	ExactFingerprints        []int    `json:"exactFingerprints"`
-	PartialMatches           []int    `json:"partialMatches"`
-	PartialMatchFingerprints struct{} `json:"partialMatchFingerprints"`
-	InstalledFingerprints    []int    `json:"installedFingerprints"`
-	UnmatchedFingerprints    []int    `json:"unmatchedFingerprints"`
+	IsCacheBuilt             bool                    `json:"isCacheBuilt"`
+	ExactMatches             []addonFingerprintMatch `json:"exactMatches"`
+	ExactFingerprints        []int                   `json:"exactFingerprints"`
+	PartialMatches           []int                   `json:"partialMatches"`
+	PartialMatchFingerprints struct{}                `json:"partialMatchFingerprints"`
+	InstalledFingerprints    []int                   `json:"installedFingerprints"`
+	UnmatchedFingerprints    []int                   `json:"unmatchedFingerprints"`
 }
 
-func getFingerprintInfo(hashes []int) (addonFingerprintResponse, error) {
+type addonFingerprintMatch struct {
+	ID          int           `json:"id"`
+	File        modFileInfo   `json:"file"`
+	LatestFiles []modFileInfo `json:"latestFiles"`
+}
+
+func getFingerprintInfo(ctx context.Context, hashes []int) (addonFingerprintResponse, error) {
 	var infoRes addonFingerprintResponse
 	client := &http.Client{}
 
@@ -412,20 +549,19 @@ func getFingerprintInfo(hashes []int) (addonFingerprintResponse, error) {
 		return addonFingerprintResponse{}, err
 	}
 
-	req, err := http.NewRequest("POST", "https://addons-ecs.forgesvc.net/api/v2/fingerprint", bytes.NewBuffer(hashesData))
+	req, err := newAPIRequest(ctx, "POST", "fingerprint", bytes.NewBuffer(hashesData))
 	if err != nil {
 		return addonFingerprintResponse{}, err
 	}
 
-	// TODO: make this configurable application-wide
-	req.Header.Set("User-Agent", "packwiz/packwiz client")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
+	resp, err := doRequest(client, req)
 	if err != nil {
 		return addonFingerprintResponse{}, err
 	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return addonFingerprintResponse{}, err
+	}
 
 	err = json.NewDecoder(resp.Body).Decode(&infoRes)
 	if err != nil && err != io.EOF {
@@ -434,3 +570,109 @@ func getFingerprintInfo(hashes []int) (addonFingerprintResponse, error) {
 
 	return infoRes, nil
 }
+
+const defaultAPIBaseURL = "https://addons-ecs.forgesvc.net/api/v2/"
+const defaultSlugAPIURL = "https://curse.nikky.moe/graphql"
+
+// getAPIBaseURL returns the base URL of the CurseForge API, which can be overridden (e.g. to use a mirror) with the
+// PACKWIZ_CURSEFORGE_API_URL environment variable or the curseforge.api-url option
+func getAPIBaseURL() string {
+	baseURL := os.Getenv("PACKWIZ_CURSEFORGE_API_URL")
+	if baseURL == "" {
+		baseURL = viper.GetString("curseforge.api-url")
+	}
+	if baseURL == "" {
+		return defaultAPIBaseURL
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return baseURL
+}
+
+// getSlugAPIURL returns the URL of the GraphQL API used to look up mod IDs from slugs, which can be overridden with the
+// PACKWIZ_CURSEFORGE_SLUG_API_URL environment variable or the curseforge.slug-api-url option
+func getSlugAPIURL() string {
+	if slugURL := os.Getenv("PACKWIZ_CURSEFORGE_SLUG_API_URL"); slugURL != "" {
+		return slugURL
+	}
+	if slugURL := viper.GetString("curseforge.slug-api-url"); slugURL != "" {
+		return slugURL
+	}
+	return defaultSlugAPIURL
+}
+
+// newRequest creates a request with the headers common to all requests, setting the content type if there is a body
+func newRequest(ctx context.Context, method string, reqURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: make this configurable application-wide
+	req.Header.Set("User-Agent", "packwiz/packwiz client")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// doRequest sends a request, transparently decompressing the response body if it is compressed
+func doRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.ReadCloser
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "gzip":
+		reader, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(resp.Body)
+	default:
+		return resp, nil
+	}
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = &decompressedBody{reader, resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return resp, nil
+}
+
+// decompressedBody reads from a decompression reader, closing both it and the original body when closed
+type decompressedBody struct {
+	io.ReadCloser
+	original io.ReadCloser
+}
+
+func (b *decompressedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if err2 := b.original.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// newAPIRequest creates a request to the given path of the CurseForge API
+func newAPIRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	req, err := newRequest(ctx, method, getAPIBaseURL()+path, body)
+	if err != nil {
+		return nil, err
+	}
+	setAPIToken(req)
+	return req, nil
+}
+
+// setAPIToken adds the configured CurseForge API token (if there is one) to a request
+func setAPIToken(req *http.Request) {
+	if token := core.GetServiceToken("curseforge"); token != "" {
+		req.Header.Set("x-api-key", token)
+	}
+}
@@ -0,0 +1,34 @@
+package curseforge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// newTestAPI starts a fake CurseForge API with the given handler, and uses it (with an empty response cache) for the
+// rest of the test
+func newTestAPI(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	oldCache := responseCache
+	SetCache(NewMemoryCache())
+	viper.Set("curseforge.api-url", server.URL)
+	t.Cleanup(func() {
+		viper.Set("curseforge.api-url", "")
+		SetCache(oldCache)
+		server.Close()
+	})
+	return server
+}
+
+// writeTestJSON writes a value as a JSON response
+func writeTestJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Error(err)
+	}
+}
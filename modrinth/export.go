@@ -1,12 +1,13 @@
 package modrinth
 
 import (
-	"archive/zip"
 	"encoding/json"
 	"fmt"
 	"github.com/spf13/viper"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/packwiz/packwiz/core"
 	"github.com/spf13/cobra"
@@ -18,43 +19,89 @@ var exportCmd = &cobra.Command{
 	Short: "Export the current modpack into a .mrpack for Modrinth",
 	Args:  cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Loading modpack...")
+		fmt.Fprintln(core.Output, "Loading modpack...")
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		index, err := pack.LoadIndex()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 		// Do a refresh to ensure files are up to date
 		err = index.Refresh()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = index.Write()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = pack.UpdateIndexHash()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 		err = pack.Write()
 		if err != nil {
-			fmt.Println(err)
-			return
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 
 		// TODO: should index just expose indexPath itself, through a function?
 		indexPath := filepath.Join(filepath.Dir(viper.GetString("pack-file")), filepath.FromSlash(pack.Index.File))
 
 		mods := loadMods(index)
+		if targetLoader := viper.GetString("modrinth.export.target-loader"); targetLoader != "" {
+			pack, err = pack.WithTargetLoader(targetLoader, viper.GetString("modrinth.export.target-loader-version"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(core.Output, "Selecting %s files...\n", core.ComponentToFriendlyName(targetLoader))
+			var skipped []string
+			mods, skipped, err = core.SelectModsForPack(cmd.Context(), mods, pack)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			for _, v := range skipped {
+				fmt.Fprintf(os.Stderr, "Warning: a supported update system for \"%s\" cannot be found, so its file is exported unchanged\n", v)
+			}
+		}
+
+		if invalidSideMods := findInvalidSideMods(mods); len(invalidSideMods) > 0 {
+			fmt.Fprintln(os.Stderr, "The following mods don't have a valid side (client, server or both), which is required for Modrinth packs:")
+			for _, v := range invalidSideMods {
+				fmt.Fprintln(os.Stderr, v)
+			}
+			os.Exit(1)
+		}
+		if viper.GetBool("modrinth.export.reproducible") {
+			core.SortModsForExport(mods)
+		}
+
+		if viper.GetBool("modrinth.export.embed-installer") {
+			if viper.GetString("modrinth.export.pack-url") == "" {
+				fmt.Fprintln(os.Stderr, "The URL of pack.toml must be given with --pack-url to embed packwiz-installer")
+				os.Exit(1)
+			}
+			err = core.ValidatePackURL(viper.GetString("modrinth.export.pack-url"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
+		overridesDir := path.Clean(viper.GetString("modrinth.export.overrides-dir"))
+		if overridesDir == "." || path.IsAbs(overridesDir) || strings.HasPrefix(overridesDir, "../") {
+			fmt.Fprintln(os.Stderr, "Invalid overrides directory!")
+			os.Exit(1)
+		}
 
 		fileName := viper.GetString("modrinth.export.output")
 		if fileName == "" {
@@ -62,20 +109,20 @@ var exportCmd = &cobra.Command{
 		}
 		expFile, err := os.Create(fileName)
 		if err != nil {
-			fmt.Printf("Failed to create zip: %s\n", err.Error())
+			fmt.Fprintf(os.Stderr, "Failed to create zip: %s\n", err.Error())
 			os.Exit(1)
 		}
-		exp := zip.NewWriter(expFile)
+		exp := core.NewExportZip(expFile)
 
 		// Add an overrides folder even if there are no files to go in it
-		_, err = exp.Create("overrides/")
+		_, err = exp.Create(overridesDir + "/")
 		if err != nil {
-			fmt.Printf("Failed to add overrides folder: %s\n", err.Error())
+			fmt.Fprintf(os.Stderr, "Failed to add overrides folder: %s\n", err.Error())
 			os.Exit(1)
 		}
 
 		// TODO: cache these (ideally with changes to pack format)
-		fmt.Println("Retrieving SHA1 hashes for external mods...")
+		fmt.Fprintln(core.Output, "Retrieving SHA1 hashes for external mods...")
 		sha1Hashes := make([]string, len(mods))
 		for i, mod := range mods {
 			if mod.Download.HashFormat == "sha1" {
@@ -88,12 +135,12 @@ var exportCmd = &cobra.Command{
 				}
 				err = mod.DownloadFile(h)
 				if err != nil {
-					fmt.Printf("Error downloading mod file %s: %s\n", mod.Download.URL, err.Error())
+					fmt.Fprintf(os.Stderr, "Error downloading mod file %s: %s\n", mod.Download.URL, err.Error())
 					// TODO: exit(1)?
 					continue
 				}
 				sha1Hashes[i] = stringer.HashToString(h.Sum(nil))
-				fmt.Printf("Retrieved SHA1 hash for %s successfully\n", mod.Download.URL)
+				fmt.Fprintf(core.Output, "Retrieved SHA1 hash for %s successfully\n", mod.Download.URL)
 			}
 		}
 
@@ -101,21 +148,12 @@ var exportCmd = &cobra.Command{
 		if err != nil {
 			_ = exp.Close()
 			_ = expFile.Close()
-			fmt.Println("Error creating manifest: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error creating manifest: "+err.Error())
 			os.Exit(1)
 		}
 
-		manifestFiles := make([]PackFile, len(mods))
+		manifestFiles := make([]PackFile, 0, len(mods))
 		for i, mod := range mods {
-			pathForward, err := filepath.Rel(filepath.Dir(indexPath), mod.GetDestFilePath())
-			if err != nil {
-				fmt.Printf("Error resolving mod file: %s\n", err.Error())
-				// TODO: exit(1)?
-				continue
-			}
-
-			path := filepath.ToSlash(pathForward)
-
 			hashes := make(map[string]string)
 			hashes["sha1"] = sha1Hashes[i]
 
@@ -141,18 +179,27 @@ var exportCmd = &cobra.Command{
 			// Modrinth URLs must be RFC3986
 			u, err := core.ReencodeURL(mod.Download.URL)
 			if err != nil {
-				fmt.Printf("Error re-encoding mod URL: %s\n", err.Error())
+				fmt.Fprintf(os.Stderr, "Error re-encoding mod URL: %s\n", err.Error())
 				u = mod.Download.URL
 			}
 
-			manifestFiles[i] = PackFile{
-				Path:   path,
-				Hashes: hashes,
-				Env: &struct {
-					Client string `json:"client"`
-					Server string `json:"server"`
-				}{Client: clientEnv, Server: serverEnv},
-				Downloads: []string{u},
+			for _, destPath := range index.GetModDestPaths(mod) {
+				pathForward, err := filepath.Rel(filepath.Dir(indexPath), destPath)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error resolving mod file: %s\n", err.Error())
+					// TODO: exit(1)?
+					continue
+				}
+
+				manifestFiles = append(manifestFiles, PackFile{
+					Path:   filepath.ToSlash(pathForward),
+					Hashes: hashes,
+					Env: &struct {
+						Client string `json:"client"`
+						Server string `json:"server"`
+					}{Client: clientEnv, Server: serverEnv},
+					Downloads: []string{u},
+				})
 			}
 		}
 
@@ -161,7 +208,7 @@ var exportCmd = &cobra.Command{
 		if err != nil {
 			_ = exp.Close()
 			_ = expFile.Close()
-			fmt.Println("Error creating manifest: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error creating manifest: "+err.Error())
 			os.Exit(1)
 		}
 		if fabricVersion, ok := pack.Versions["fabric"]; ok {
@@ -181,7 +228,7 @@ var exportCmd = &cobra.Command{
 		}
 
 		if len(pack.Version) == 0 {
-			fmt.Println("Warning: pack.toml version field must not be empty to create a valid Modrinth pack")
+			fmt.Fprintln(os.Stderr, "Warning: pack.toml version field must not be empty to create a valid Modrinth pack")
 		}
 
 		w := json.NewEncoder(manifestFile)
@@ -190,58 +237,108 @@ var exportCmd = &cobra.Command{
 		if err != nil {
 			_ = exp.Close()
 			_ = expFile.Close()
-			fmt.Println("Error writing manifest: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error writing manifest: "+err.Error())
 			os.Exit(1)
 		}
 
 		for _, v := range index.Files {
 			if !v.MetaFile {
 				// Save all non-metadata files into the zip
-				path, err := filepath.Rel(filepath.Dir(indexPath), index.GetFilePath(v))
+				path, err := filepath.Rel(filepath.Dir(indexPath), index.GetFileDestPath(v))
 				if err != nil {
-					fmt.Printf("Error resolving file: %s\n", err.Error())
+					fmt.Fprintf(os.Stderr, "Error resolving file: %s\n", err.Error())
 					// TODO: exit(1)?
 					continue
 				}
-				file, err := exp.Create(filepath.ToSlash(filepath.Join("overrides", path)))
+				file, err := exp.Create(overridesDir + "/" + filepath.ToSlash(path))
 				if err != nil {
-					fmt.Printf("Error creating file: %s\n", err.Error())
+					fmt.Fprintf(os.Stderr, "Error creating file: %s\n", err.Error())
 					// TODO: exit(1)?
 					continue
 				}
 				err = index.SaveFile(v, file)
 				if err != nil {
-					fmt.Printf("Error copying file: %s\n", err.Error())
+					fmt.Fprintf(os.Stderr, "Error copying file: %s\n", err.Error())
 					// TODO: exit(1)?
 					continue
 				}
 			}
 		}
 
+		if viper.GetBool("modrinth.export.embed-installer") {
+			fmt.Fprintln(core.Output, "Downloading packwiz-installer...")
+			err = core.WriteEmbeddedInstaller(exp, overridesDir, viper.GetString("modrinth.export.installer-url"), viper.GetString("modrinth.export.pack-url"), core.ClientSide)
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintln(os.Stderr, "Error embedding packwiz-installer: "+err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if viper.GetBool("modrinth.export.bom") {
+			bomFile, err := exp.Create(core.BOMFile)
+			if err == nil {
+				err = core.WriteBOM(bomFile, pack, index, mods)
+			}
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintln(os.Stderr, "Error creating bill of materials: "+err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if viper.GetBool("modrinth.export.license-summary") {
+			fmt.Fprintln(core.Output, "Retrieving licenses...")
+			licenseFile, err := exp.Create(core.LicenseSummaryFile)
+			if err == nil {
+				err = core.WriteLicenseSummary(cmd.Context(), licenseFile, pack.Name, mods)
+			}
+			if err != nil {
+				_ = exp.Close()
+				_ = expFile.Close()
+				fmt.Fprintln(os.Stderr, "Error creating license summary: "+err.Error())
+				os.Exit(1)
+			}
+		}
+
 		err = exp.Close()
 		if err != nil {
-			fmt.Println("Error writing export file: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error writing export file: "+err.Error())
 			os.Exit(1)
 		}
 		err = expFile.Close()
 		if err != nil {
-			fmt.Println("Error writing export file: " + err.Error())
+			fmt.Fprintln(os.Stderr, "Error writing export file: "+err.Error())
 			os.Exit(1)
 		}
 
-		fmt.Println("Modpack exported to " + fileName)
+		fmt.Fprintln(core.Output, "Modpack exported to "+fileName)
 	},
 }
 
+// findInvalidSideMods returns a description of each mod that doesn't have a valid side; the env object of every file in
+// a Modrinth pack must say whether it is used on the client and server
+func findInvalidSideMods(mods []core.Mod) []string {
+	var invalidSideMods []string
+	for _, mod := range mods {
+		if mod.Side != core.UniversalSide && mod.Side != core.ClientSide && mod.Side != core.ServerSide {
+			invalidSideMods = append(invalidSideMods, fmt.Sprintf("%s (side \"%s\")", mod.Name, mod.Side))
+		}
+	}
+	return invalidSideMods
+}
+
 func loadMods(index core.Index) []core.Mod {
 	modPaths := index.GetAllMods()
 	mods := make([]core.Mod, len(modPaths))
 	i := 0
-	fmt.Println("Reading mod files...")
+	fmt.Fprintln(core.Output, "Reading mod files...")
 	for _, v := range modPaths {
 		modData, err := core.LoadMod(v)
 		if err != nil {
-			fmt.Printf("Error reading mod file %s: %s\n", v, err.Error())
+			fmt.Fprintf(os.Stderr, "Error reading mod file %s: %s\n", v, err.Error())
 			// TODO: exit(1)?
 			continue
 		}
@@ -256,4 +353,22 @@ func init() {
 	modrinthCmd.AddCommand(exportCmd)
 	exportCmd.Flags().StringP("output", "o", "", "The file to export the modpack to")
 	_ = viper.BindPFlag("modrinth.export.output", exportCmd.Flags().Lookup("output"))
+	exportCmd.Flags().String("overrides-dir", "overrides", "The name of the folder in the exported pack to store override files in (note that the Modrinth format expects \"overrides\")")
+	_ = viper.BindPFlag("modrinth.export.overrides-dir", exportCmd.Flags().Lookup("overrides-dir"))
+	exportCmd.Flags().String("target-loader", "", "Export a variant of the pack for this loader (e.g. fabric or forge), selecting a compatible file for each mod")
+	_ = viper.BindPFlag("modrinth.export.target-loader", exportCmd.Flags().Lookup("target-loader"))
+	exportCmd.Flags().String("target-loader-version", "", "The version of the target loader to use (defaults to the pack's version of the loader, or the latest version)")
+	_ = viper.BindPFlag("modrinth.export.target-loader-version", exportCmd.Flags().Lookup("target-loader-version"))
+	exportCmd.Flags().Bool("reproducible", false, "Sort the exported files, so that exporting the same pack always produces an identical pack")
+	_ = viper.BindPFlag("modrinth.export.reproducible", exportCmd.Flags().Lookup("reproducible"))
+	exportCmd.Flags().Bool("embed-installer", false, "Include the packwiz-installer bootstrap jar, a config file and scripts to run it, so that the pack can update itself from --pack-url")
+	_ = viper.BindPFlag("modrinth.export.embed-installer", exportCmd.Flags().Lookup("embed-installer"))
+	exportCmd.Flags().String("installer-url", core.DefaultInstallerURL, "The URL to download the packwiz-installer bootstrap jar from, when using --embed-installer")
+	_ = viper.BindPFlag("modrinth.export.installer-url", exportCmd.Flags().Lookup("installer-url"))
+	exportCmd.Flags().String("pack-url", "", "The URL of the pack's pack.toml, for packwiz-installer to update the pack from when using --embed-installer")
+	_ = viper.BindPFlag("modrinth.export.pack-url", exportCmd.Flags().Lookup("pack-url"))
+	exportCmd.Flags().Bool("bom", false, "Include a bill of materials ("+core.BOMFile+") listing the source, version, hash and size of every file in the pack")
+	_ = viper.BindPFlag("modrinth.export.bom", exportCmd.Flags().Lookup("bom"))
+	exportCmd.Flags().Bool("license-summary", false, "Include a summary of the licenses of all mods, in SPDX format")
+	_ = viper.BindPFlag("modrinth.export.license-summary", exportCmd.Flags().Lookup("license-summary"))
 }
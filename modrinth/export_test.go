@@ -0,0 +1,147 @@
+package modrinth
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/packwiz/packwiz/core"
+)
+
+// writeTestPack writes a Fabric pack with the given files (keyed by path relative to pack.toml), returning the path of
+// pack.toml
+func writeTestPack(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["pack.toml"] = "name = \"Test Pack\"\nversion = \"1.0.0\"\npack-format = \"packwiz:1.0.0\"\n\n[index]\nfile = \"index.toml\"\nhash-format = \"sha256\"\n\n[versions]\nminecraft = \"1.18.2\"\nfabric = \"0.13.3\"\n"
+	files["index.toml"] = "hash-format = \"sha256\"\n"
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "pack.toml")
+}
+
+// testModMetadata returns the metadata of a mod with a SHA1 hash, so that it doesn't need to be downloaded to export it
+func testModMetadata(name string, fileName string, side string) string {
+	return "name = \"" + name + "\"\nfilename = \"" + fileName + "\"\nside = \"" + side + "\"\n\n[download]\nurl = \"https://cdn.modrinth.com/data/" +
+		fileName + "\"\nhash-format = \"sha1\"\nhash = \"da39a3ee5e6b4b0d3255bfef95601890afd80709\"\n"
+}
+
+// runExport runs the export command on a pack with the given arguments, returning the exported pack
+func runExport(t *testing.T, packFile string, args ...string) []byte {
+	t.Helper()
+	output := filepath.Join(t.TempDir(), "export.mrpack")
+	root := exportCmd.Root()
+	root.SetArgs(append([]string{"modrinth", "export", "--quiet", "--pack-file", packFile, "--output", output}, args...))
+	defer root.SetArgs(nil)
+	if err := root.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// readExportManifest returns the manifest of an exported pack
+func readExportManifest(t *testing.T, data []byte) Pack {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open("modrinth.index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var manifest Pack
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestExportReproducible(t *testing.T) {
+	// The metadata files are in the opposite order to the files they install
+	packFile := writeTestPack(t, map[string]string{
+		"mods/a.pw.toml":       testModMetadata("A", "z.jar", "both"),
+		"mods/b.pw.toml":       testModMetadata("B", "y.jar", "client"),
+		"config/settings.json": "{}",
+	})
+
+	first := runExport(t, packFile, "--reproducible", "--overrides-dir", "overrides")
+	// Modification times of the pack's files aren't included in the export
+	later := time.Now().Add(time.Hour)
+	for _, path := range []string{"mods/a.pw.toml", "mods/b.pw.toml", "config/settings.json"} {
+		if err := os.Chtimes(filepath.Join(filepath.Dir(packFile), filepath.FromSlash(path)), later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+	second := runExport(t, packFile, "--reproducible", "--overrides-dir", "overrides")
+	if !bytes.Equal(first, second) {
+		t.Errorf("exporting the same pack twice produced different archives")
+	}
+
+	var paths []string
+	for _, f := range readExportManifest(t, first).Files {
+		paths = append(paths, f.Path)
+	}
+	if len(paths) != 2 || paths[0] != "mods/y.jar" || paths[1] != "mods/z.jar" {
+		t.Errorf("files are exported in the order %v, want them sorted by path", paths)
+	}
+}
+
+func TestExportOverridesDir(t *testing.T) {
+	packFile := writeTestPack(t, map[string]string{
+		"mods/a.pw.toml":       testModMetadata("A", "a.jar", "both"),
+		"config/settings.json": "{}",
+	})
+
+	data := runExport(t, packFile, "--overrides-dir", "client-overrides")
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "overrides/") {
+			t.Errorf("%s was exported to the default overrides folder", f.Name)
+		}
+		if f.Name == "client-overrides/config/settings.json" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("config/settings.json wasn't exported to the client-overrides folder")
+	}
+}
+
+func TestFindInvalidSideMods(t *testing.T) {
+	mods := []core.Mod{
+		{Name: "Both", Side: core.UniversalSide},
+		{Name: "Client", Side: core.ClientSide},
+		{Name: "Server", Side: core.ServerSide},
+		{Name: "No Side"},
+		{Name: "Typo", Side: "clinet"},
+	}
+	want := []string{"No Side (side \"\")", "Typo (side \"clinet\")"}
+	if got := findInvalidSideMods(mods); !reflect.DeepEqual(got, want) {
+		t.Errorf("findInvalidSideMods() = %q, want %q", got, want)
+	}
+}
@@ -1,9 +1,12 @@
 package modrinth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/spf13/viper"
+	"io"
+	"net/http"
 	"os"
 	"regexp"
 	"strings"
@@ -15,6 +18,7 @@ import (
 
 var modSiteRegex = regexp.MustCompile("modrinth\\.com/mod/([^/]+)/?$")
 var versionSiteRegex = regexp.MustCompile("modrinth\\.com/mod/([^/]+)/version/([^/]+)/?$")
+var cdnURLRegex = regexp.MustCompile("^https?://cdn\\.modrinth\\.com/data/([^/]+)/versions/([^/]+)/[^/]+$")
 
 // installCmd represents the install command
 var installCmd = &cobra.Command{
@@ -25,20 +29,20 @@ var installCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		pack, err := core.LoadPack()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
 		if len(args) == 0 || len(args[0]) == 0 {
-			fmt.Println("You must specify a mod.")
+			fmt.Fprintln(os.Stderr, "You must specify a mod.")
 			os.Exit(1)
 		}
 
 		// If there are more than 1 argument, go straight to searching - URLs/Slugs should not have spaces!
 		if len(args) > 1 {
-			err = installViaSearch(strings.Join(args, " "), pack)
+			err = installViaSearch(cmd.Context(), strings.Join(args, " "), pack)
 			if err != nil {
-				fmt.Printf("Failed installing mod: %s\n", err)
+				fmt.Fprintf(os.Stderr, "Failed installing mod: %s\n", err)
 				os.Exit(1)
 			}
 			return
@@ -47,9 +51,20 @@ var installCmd = &cobra.Command{
 		//Try interpreting the arg as a version url
 		matches := versionSiteRegex.FindStringSubmatch(args[0])
 		if matches != nil && len(matches) == 3 {
-			err = installVersionById(matches[2], pack)
+			err = installVersionById(cmd.Context(), matches[2], pack)
 			if err != nil {
-				fmt.Printf("Failed installing mod: %s\n", err)
+				fmt.Fprintf(os.Stderr, "Failed installing mod: %s\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		//Try interpreting the arg as a file download url
+		matches = cdnURLRegex.FindStringSubmatch(args[0])
+		if matches != nil && len(matches) == 3 {
+			err = installVersionByCDNURL(cmd.Context(), args[0], matches[1], matches[2], pack)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed installing mod: %s\n", err)
 				os.Exit(1)
 			}
 			return
@@ -68,13 +83,13 @@ var installCmd = &cobra.Command{
 			modStr = args[0]
 		}
 
-		mod, err := fetchMod(modStr)
+		mod, err := fetchMod(cmd.Context(), modStr)
 
 		if err == nil {
 			//We found a mod with that id/slug
-			err = installMod(mod, pack)
+			err = installMod(cmd.Context(), mod, pack)
 			if err != nil {
-				fmt.Printf("Failed installing mod: %s\n", err)
+				fmt.Fprintf(os.Stderr, "Failed installing mod: %s\n", err)
 				os.Exit(1)
 			}
 			return
@@ -82,32 +97,33 @@ var installCmd = &cobra.Command{
 			//This wasn't a valid modid/slug, try to search for it instead:
 			//Don't bother to search if it looks like a url though
 			if matches == nil {
-				err = installViaSearch(args[0], pack)
+				err = installViaSearch(cmd.Context(), args[0], pack)
 				if err != nil {
-					fmt.Printf("Failed installing mod: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Failed installing mod: %s\n", err)
 					os.Exit(1)
 				}
 			} else {
-				fmt.Printf("Failed installing mod: %s\n", err)
+				fmt.Fprintf(os.Stderr, "Failed installing mod: %s\n", err)
 				os.Exit(1)
 			}
 		}
 	},
 }
 
-func installViaSearch(query string, pack core.Pack) error {
+func installViaSearch(ctx context.Context, query string, pack core.Pack) error {
 	mcVersion, err := pack.GetMCVersion()
 	if err != nil {
 		return err
 	}
 
-	results, err := getModIdsViaSearch(query, append([]string{mcVersion}, viper.GetStringSlice("acceptable-game-versions")...))
+	results, err := getModIdsViaSearch(ctx, query, append([]string{mcVersion}, viper.GetStringSlice("acceptable-game-versions")...))
 	if err != nil {
 		return err
 	}
 
 	//Create menu for the user to choose the correct mod
 	menu := wmenu.NewMenu("Choose a number:")
+	menu.ChangeReaderWriter(os.Stdin, core.PromptOutput, os.Stderr)
 	menu.Option("Cancel", nil, false, nil)
 	for i, v := range results {
 		menu.Option(v.Title, v, i == 0, nil)
@@ -127,21 +143,22 @@ func installViaSearch(query string, pack core.Pack) error {
 		//Install the selected mod
 		modId := strings.TrimPrefix(selectedMod.ModID, "local-")
 
-		mod, err := fetchMod(modId)
+		mod, err := fetchMod(ctx, modId)
 		if err != nil {
 			return err
 		}
 
-		return installMod(mod, pack)
+		return installMod(ctx, mod, pack)
 	})
 
 	return menu.Run()
 }
 
-func installMod(mod Mod, pack core.Pack) error {
-	fmt.Printf("Found mod %s: '%s'.\n", mod.Title, mod.Description)
+func installMod(ctx context.Context, mod Mod, pack core.Pack) error {
+	fmt.Fprintf(core.Output, "Found mod %s: '%s'.\n", mod.Title, mod.Description)
 
-	latestVersion, err := getLatestVersion(mod.ID, pack)
+	// When installing a mod, the version recommended by the author is used if there are several compatible versions
+	latestVersion, err := getLatestVersion(ctx, mod.ID, pack)
 	if err != nil {
 		return err
 	}
@@ -169,7 +186,11 @@ func installVersion(mod Mod, version Version, pack core.Pack) error {
 	}
 
 	//Install the file
-	fmt.Printf("Installing %s from version %s\n", file.Filename, version.VersionNumber)
+	if file.Size > 0 {
+		fmt.Fprintf(core.Output, "Installing %s (%s) from version %s\n", file.Filename, core.FormatFileSize(file.Size), version.VersionNumber)
+	} else {
+		fmt.Fprintf(core.Output, "Installing %s from version %s\n", file.Filename, version.VersionNumber)
+	}
 	index, err := pack.LoadIndex()
 	if err != nil {
 		return err
@@ -189,6 +210,13 @@ func installVersion(mod Mod, version Version, pack core.Pack) error {
 	if side == "" {
 		return errors.New("version doesn't have a side that's supported. Server: " + mod.ServerSide + " Client: " + mod.ClientSide)
 	}
+	// Mods that work on both sides use the default side, which can be set to only add them to e.g. server packs
+	if side == core.UniversalSide {
+		side, err = core.GetDefaultSide(viper.GetString("modrinth.install.side"))
+		if err != nil {
+			return err
+		}
+	}
 
 	algorithm, hash := file.getBestHash()
 	if algorithm == "" {
@@ -241,13 +269,13 @@ func installVersion(mod Mod, version Version, pack core.Pack) error {
 	return nil
 }
 
-func installVersionById(versionId string, pack core.Pack) error {
-	version, err := fetchVersion(versionId)
+func installVersionById(ctx context.Context, versionId string, pack core.Pack) error {
+	version, err := fetchVersion(ctx, versionId)
 	if err != nil {
 		return err
 	}
 
-	mod, err := fetchMod(version.ModID)
+	mod, err := fetchMod(ctx, version.ModID)
 	if err != nil {
 		return err
 	}
@@ -255,6 +283,86 @@ func installVersionById(versionId string, pack core.Pack) error {
 	return installVersion(mod, version, pack)
 }
 
+// installVersionByCDNURL installs the version that a file on the Modrinth CDN is from. The URL contains the version ID
+// for newer files, but older files use the version number instead, so these are found using the hash of the file.
+func installVersionByCDNURL(ctx context.Context, fileURL string, modID string, versionStr string, pack core.Pack) error {
+	version, err := fetchVersion(ctx, versionStr)
+	if err != nil || version.ModID != modID {
+		h, stringer, err := core.GetHashImpl("sha1")
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+		}
+		_, err = io.Copy(h, resp.Body)
+		if err != nil {
+			return err
+		}
+
+		var found bool
+		version, found, err = fetchVersionByHash(ctx, stringer.HashToString(h.Sum(nil)))
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errors.New("failed to find the version for " + fileURL)
+		}
+	}
+
+	mod, err := fetchMod(ctx, version.ModID)
+	if err != nil {
+		return err
+	}
+
+	return installVersion(mod, version, pack)
+}
+
+type mrSource struct{}
+
+func (mrSource) FindMod(ctx context.Context, query string, pack core.Pack) (core.FoundMod, bool, error) {
+	mod, err := fetchMod(ctx, query)
+	if err != nil {
+		// Not a slug or ID; search for it instead
+		mcVersion, err := pack.GetMCVersion()
+		if err != nil {
+			return core.FoundMod{}, false, err
+		}
+		results, err := getModIdsViaSearch(ctx, query, append([]string{mcVersion}, viper.GetStringSlice("acceptable-game-versions")...))
+		if err != nil {
+			return core.FoundMod{}, false, err
+		}
+		if len(results) == 0 {
+			return core.FoundMod{}, false, nil
+		}
+		mod, err = fetchMod(ctx, strings.TrimPrefix(results[0].ModID, "local-"))
+		if err != nil {
+			return core.FoundMod{}, false, err
+		}
+	}
+
+	return core.FoundMod{
+		Name: mod.Title,
+		URL:  "https://modrinth.com/mod/" + mod.Slug,
+		Install: func() error {
+			return installMod(ctx, mod, pack)
+		},
+	}, true, nil
+}
+
 func init() {
 	modrinthCmd.AddCommand(installCmd)
+	core.ModSources["modrinth"] = mrSource{}
+
+	installCmd.Flags().String("side", "", "The side to add mods that work on both sides with (client, server or both; defaults to the default-side option)")
+	_ = viper.BindPFlag("modrinth.install.side", installCmd.Flags().Lookup("side"))
 }
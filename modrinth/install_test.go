@@ -0,0 +1,95 @@
+package modrinth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+	"github.com/spf13/viper"
+)
+
+func TestFindMod(t *testing.T) {
+	sodium := Mod{ID: "AANobbMI", Slug: "sodium", Title: "Sodium", ClientSide: "required", ServerSide: "unsupported"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.URL.Path {
+		case "/mod/sodium", "/mod/AANobbMI":
+			_ = json.NewEncoder(w).Encode(sodium)
+		case "/mod":
+			var hits []ModResult
+			if req.URL.Query().Get("query") == "Sodium Renderer" {
+				hits = append(hits, ModResult{ModID: "local-AANobbMI", Title: "Sodium"})
+			}
+			_ = json.NewEncoder(w).Encode(ModSearchResult{Hits: hits, TotalHits: len(hits)})
+		case "/mod/AANobbMI/version":
+			_ = json.NewEncoder(w).Encode([]Version{{
+				ID:            "sodium-2",
+				VersionNumber: "0.4.1",
+				DatePublished: "2022-03-01T00:00:00Z",
+				Files: []VersionFile{{
+					Hashes:   map[string]string{"sha1": "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+					Url:      "https://cdn.modrinth.com/data/AANobbMI/sodium-fabric-0.4.1.jar",
+					Filename: "sodium-fabric-0.4.1.jar",
+					Primary:  true,
+				}},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	viper.Set("modrinth.api-url", server.URL)
+	defer viper.Set("modrinth.api-url", "")
+
+	packFile := writeTestPack(t, map[string]string{})
+	viper.Set("pack-file", packFile)
+	defer viper.Set("pack-file", "")
+	pack, err := core.LoadPack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{"sodium", "Sodium Renderer"} {
+		found, ok, err := mrSource{}.FindMod(context.Background(), query, pack)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || found.Name != "Sodium" || found.URL != "https://modrinth.com/mod/sodium" {
+			t.Errorf("FindMod(%q) = %v, %q (%s), want Sodium", query, ok, found.Name, found.URL)
+		}
+	}
+	if _, ok, _ := (mrSource{}).FindMod(context.Background(), "Missing Mod", pack); ok {
+		t.Error("FindMod() found a missing mod")
+	}
+
+	// The found mod is installed to the pack
+	found, _, err := mrSource{}.FindMod(context.Background(), "sodium", pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldOutput := core.Output
+	core.Output = ioutil.Discard
+	defer func() { core.Output = oldOutput }()
+	if err := found.Install(); err != nil {
+		t.Fatal(err)
+	}
+	index, err := pack.LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mods := index.GetAllMods()
+	if len(mods) != 1 {
+		t.Fatalf("installed %d mods, want 1", len(mods))
+	}
+	mod, err := core.LoadMod(mods[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mod.Name != "Sodium" || mod.FileName != "sodium-fabric-0.4.1.jar" || mod.Side != core.ClientSide {
+		t.Errorf("installed %s (%s, side %s), want Sodium", mod.Name, mod.FileName, mod.Side)
+	}
+}
@@ -1,12 +1,16 @@
 package modrinth
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/spf13/viper"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -15,9 +19,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-const modrinthApiUrl = "https://api.modrinth.com/api/v1/"
+const defaultModrinthApiUrl = "https://api.modrinth.com/api/v1/"
 
-var modrinthApiUrlParsed, _ = url.Parse(modrinthApiUrl)
+// errProjectRemoved is returned when a project has been removed from Modrinth (with a 410 Gone response)
+var errProjectRemoved = errors.New("project has been removed from Modrinth")
 
 var modrinthCmd = &cobra.Command{
 	Use:     "modrinth",
@@ -28,6 +33,7 @@ var modrinthCmd = &cobra.Command{
 func init() {
 	cmd.Add(modrinthCmd)
 	core.Updaters["modrinth"] = mrUpdater{}
+	core.FileIdentifiers["modrinth"] = mrIdentifier{}
 }
 
 type License struct {
@@ -114,11 +120,14 @@ type VersionFile struct {
 	Url      string            //A direct link to the file
 	Filename string            //The name of the file
 	Primary  bool              // Is the file the primary file?
+	Size     int64             //The size of the file in bytes
 }
 
-func getModIdsViaSearch(query string, versions []string) ([]ModResult, error) {
-	baseUrl := *modrinthApiUrlParsed
-	baseUrl.Path += "mod"
+func getModIdsViaSearch(ctx context.Context, query string, versions []string) ([]ModResult, error) {
+	baseUrl, err := url.Parse(getModrinthApiUrl() + "mod")
+	if err != nil {
+		return []ModResult{}, err
+	}
 
 	params := url.Values{}
 	params.Add("limit", "5")
@@ -136,7 +145,7 @@ func getModIdsViaSearch(query string, versions []string) ([]ModResult, error) {
 
 	baseUrl.RawQuery = params.Encode()
 
-	resp, err := http.Get(baseUrl.String())
+	resp, err := modrinthGet(ctx, baseUrl.String())
 	if err != nil {
 		return []ModResult{}, err
 	}
@@ -160,7 +169,9 @@ func getModIdsViaSearch(query string, versions []string) ([]ModResult, error) {
 	return result.Hits, nil
 }
 
-func getLatestVersion(modID string, pack core.Pack) (Version, error) {
+// getLatestVersion finds the newest version of a mod that is compatible with the pack, preferring featured versions over
+// newer versions that aren't featured
+func getLatestVersion(ctx context.Context, modID string, pack core.Pack) (Version, error) {
 	mcVersion, err := pack.GetMCVersion()
 	if err != nil {
 		return Version{}, err
@@ -173,10 +184,10 @@ func getLatestVersion(modID string, pack core.Pack) (Version, error) {
 
 	loader := getLoader(pack)
 
-	baseUrl := *modrinthApiUrlParsed
-	baseUrl.Path += "mod/"
-	baseUrl.Path += modID
-	baseUrl.Path += "/version"
+	baseUrl, err := url.Parse(getModrinthApiUrl() + "mod/" + url.PathEscape(modID) + "/version")
+	if err != nil {
+		return Version{}, err
+	}
 
 	params := url.Values{}
 	params.Add("game_versions", string(gameVersionsEncoded))
@@ -186,16 +197,19 @@ func getLatestVersion(modID string, pack core.Pack) (Version, error) {
 
 	baseUrl.RawQuery = params.Encode()
 
-	resp, err := http.Get(baseUrl.String())
+	resp, err := modrinthGet(ctx, baseUrl.String())
 	if err != nil {
 		return Version{}, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode == 404 {
 		return Version{}, errors.New("couldn't find mod: " + modID)
 	}
+	if resp.StatusCode == http.StatusGone {
+		return Version{}, fmt.Errorf("%w: %s", errProjectRemoved, modID)
+	}
 
-	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return Version{}, err
@@ -211,8 +225,22 @@ func getLatestVersion(modID string, pack core.Pack) (Version, error) {
 		return Version{}, errors.New("no valid versions found")
 	}
 
-	latestValidVersion := result[0]
-	for _, v := range result[1:] {
+	return selectLatestVersion(result), nil
+}
+
+// selectLatestVersion returns the newest of the given versions, by version number if they are valid semver, otherwise
+// by release date (and then by ID, so the same version is always chosen). Featured versions are chosen over versions
+// that aren't featured, so that installing and updating a mod select the same version.
+func selectLatestVersion(versions []Version) Version {
+	latestValidVersion := versions[0]
+	for _, v := range versions[1:] {
+		if v.Featured != latestValidVersion.Featured {
+			if v.Featured {
+				latestValidVersion = v
+			}
+			continue
+		}
+
 		currVersion, err1 := semver.NewVersion(v.VersionNumber)
 		latestVersion, err2 := semver.NewVersion(latestValidVersion.VersionNumber)
 		var semverCompare = 0
@@ -225,30 +253,32 @@ func getLatestVersion(modID string, pack core.Pack) (Version, error) {
 			//Semver is equal, compare date instead
 			vDate, _ := time.Parse(time.RFC3339Nano, v.DatePublished)
 			latestDate, _ := time.Parse(time.RFC3339Nano, latestValidVersion.DatePublished)
-			if vDate.After(latestDate) {
+			if vDate.After(latestDate) || (vDate.Equal(latestDate) && v.ID > latestValidVersion.ID) {
 				latestValidVersion = v
 			}
 		} else if semverCompare == 1 {
 			latestValidVersion = v
 		}
 	}
-
-	return latestValidVersion, nil
+	return latestValidVersion
 }
 
-func fetchMod(modID string) (Mod, error) {
+func fetchMod(ctx context.Context, modID string) (Mod, error) {
 	var mod Mod
 
-	resp, err := http.Get(modrinthApiUrl + "mod/" + modID)
+	resp, err := modrinthGet(ctx, getModrinthApiUrl()+"mod/"+url.PathEscape(modID))
 	if err != nil {
 		return mod, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode == 404 {
 		return mod, errors.New("couldn't find mod: " + modID)
 	}
+	if resp.StatusCode == http.StatusGone {
+		return mod, fmt.Errorf("%w: %s", errProjectRemoved, modID)
+	}
 
-	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return mod, err
@@ -266,19 +296,22 @@ func fetchMod(modID string) (Mod, error) {
 	return mod, nil
 }
 
-func fetchVersion(versionId string) (Version, error) {
+func fetchVersion(ctx context.Context, versionId string) (Version, error) {
 	var version Version
 
-	resp, err := http.Get(modrinthApiUrl + "version/" + versionId)
+	resp, err := modrinthGet(ctx, getModrinthApiUrl()+"version/"+url.PathEscape(versionId))
 	if err != nil {
 		return version, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode == 404 {
 		return version, errors.New("couldn't find version: " + versionId)
 	}
+	if resp.StatusCode == http.StatusGone {
+		return version, fmt.Errorf("%w: %s", errProjectRemoved, versionId)
+	}
 
-	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return version, err
@@ -296,6 +329,62 @@ func fetchVersion(versionId string) (Version, error) {
 	return version, nil
 }
 
+// fetchVersionByHash finds the version that a file is from, given its SHA1 hash, returning false if it wasn't found
+func fetchVersionByHash(ctx context.Context, sha1Hash string) (Version, bool, error) {
+	var version Version
+
+	resp, err := modrinthGet(ctx, getModrinthApiUrl()+"version_file/"+sha1Hash+"?algorithm=sha1")
+	if err != nil {
+		return version, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return version, false, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return version, false, err
+	}
+
+	err = json.Unmarshal(body, &version)
+	if err != nil {
+		return version, false, err
+	}
+
+	if version.ID == "" {
+		return version, false, errors.New("invalid json whilst fetching version for hash: " + sha1Hash)
+	}
+
+	return version, true, nil
+}
+
+type mrIdentifier struct{}
+
+func (mrIdentifier) IdentifyFile(ctx context.Context, data []byte) (core.FileIdentification, bool, error) {
+	h, stringer, err := core.GetHashImpl("sha1")
+	if err != nil {
+		return core.FileIdentification{}, false, err
+	}
+	h.Write(data)
+
+	version, found, err := fetchVersionByHash(ctx, stringer.HashToString(h.Sum(nil)))
+	if err != nil || !found {
+		return core.FileIdentification{}, false, err
+	}
+
+	mod, err := fetchMod(ctx, version.ModID)
+	if err != nil {
+		return core.FileIdentification{}, false, err
+	}
+	return core.FileIdentification{
+		ProjectName: mod.Title,
+		URL:         "https://modrinth.com/mod/" + mod.Slug,
+		Version:     version.VersionNumber,
+	}, true, nil
+}
+
 func (mod Mod) getSide() string {
 	server := shouldDownloadOnSide(mod.ServerSide)
 	client := shouldDownloadOnSide(mod.ClientSide)
@@ -358,3 +447,31 @@ func getLoader(pack core.Pack) string {
 		return "any"
 	}
 }
+
+// getModrinthApiUrl returns the base URL of the Modrinth API, which can be overridden (e.g. to use a mirror) with the
+// PACKWIZ_MODRINTH_API_URL environment variable or the modrinth.api-url option
+func getModrinthApiUrl() string {
+	baseUrl := os.Getenv("PACKWIZ_MODRINTH_API_URL")
+	if baseUrl == "" {
+		baseUrl = viper.GetString("modrinth.api-url")
+	}
+	if baseUrl == "" {
+		return defaultModrinthApiUrl
+	}
+	if !strings.HasSuffix(baseUrl, "/") {
+		baseUrl += "/"
+	}
+	return baseUrl
+}
+
+// modrinthGet sends a GET request to the Modrinth API, authenticated with the configured token if there is one
+func modrinthGet(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := core.GetServiceToken("modrinth"); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return http.DefaultClient.Do(req)
+}
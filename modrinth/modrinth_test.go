@@ -0,0 +1,71 @@
+package modrinth
+
+import "testing"
+
+func TestSelectLatestVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []Version
+		wantID   string
+	}{
+		{
+			name: "newest semver",
+			versions: []Version{
+				{ID: "a", VersionNumber: "1.0.0", DatePublished: "2021-01-01T00:00:00Z"},
+				{ID: "b", VersionNumber: "1.2.0", DatePublished: "2021-01-01T00:00:00Z"},
+				{ID: "c", VersionNumber: "1.1.0", DatePublished: "2021-06-01T00:00:00Z"},
+			},
+			wantID: "b",
+		},
+		{
+			name: "newest date without semver",
+			versions: []Version{
+				{ID: "a", VersionNumber: "mc1.18-build5", DatePublished: "2021-06-01T00:00:00Z"},
+				{ID: "b", VersionNumber: "mc1.18-build4", DatePublished: "2021-01-01T00:00:00Z"},
+			},
+			wantID: "a",
+		},
+		{
+			name: "featured over newer",
+			versions: []Version{
+				{ID: "a", VersionNumber: "2.0.0", DatePublished: "2021-06-01T00:00:00Z"},
+				{ID: "b", VersionNumber: "1.0.0", DatePublished: "2021-01-01T00:00:00Z", Featured: true},
+				{ID: "c", VersionNumber: "2.1.0", DatePublished: "2021-07-01T00:00:00Z"},
+			},
+			wantID: "b",
+		},
+		{
+			name: "newest of several featured",
+			versions: []Version{
+				{ID: "a", VersionNumber: "1.0.0", DatePublished: "2021-01-01T00:00:00Z", Featured: true},
+				{ID: "b", VersionNumber: "3.0.0", DatePublished: "2021-08-01T00:00:00Z"},
+				{ID: "c", VersionNumber: "1.5.0", DatePublished: "2021-03-01T00:00:00Z", Featured: true},
+			},
+			wantID: "c",
+		},
+		{
+			name: "same date chosen by ID",
+			versions: []Version{
+				{ID: "b", VersionNumber: "build", DatePublished: "2021-01-01T00:00:00Z"},
+				{ID: "c", VersionNumber: "build", DatePublished: "2021-01-01T00:00:00Z"},
+				{ID: "a", VersionNumber: "build", DatePublished: "2021-01-01T00:00:00Z"},
+			},
+			wantID: "c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectLatestVersion(tt.versions); got.ID != tt.wantID {
+				t.Errorf("selectLatestVersion() = %s, want %s", got.ID, tt.wantID)
+			}
+			// The order of the versions must not change the result
+			reversed := make([]Version, len(tt.versions))
+			for i, v := range tt.versions {
+				reversed[len(tt.versions)-1-i] = v
+			}
+			if got := selectLatestVersion(reversed); got.ID != tt.wantID {
+				t.Errorf("selectLatestVersion() of reversed versions = %s, want %s", got.ID, tt.wantID)
+			}
+		})
+	}
+}
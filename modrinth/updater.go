@@ -1,6 +1,7 @@
 package modrinth
 
 import (
+	"context"
 	"errors"
 
 	"github.com/mitchellh/mapstructure"
@@ -10,6 +11,8 @@ import (
 type mrUpdateData struct {
 	ModID            string `mapstructure:"mod-id"`
 	InstalledVersion string `mapstructure:"version"`
+	// Removed is set once the project has been removed from Modrinth, so that it is reported without querying the API
+	Removed bool `mapstructure:"removed,omitempty"`
 }
 
 func (u mrUpdateData) ToMap() (map[string]interface{}, error) {
@@ -26,12 +29,69 @@ func (u mrUpdater) ParseUpdate(updateUnparsed map[string]interface{}) (interface
 	return updateData, err
 }
 
+// ResolveLicenses finds the licenses of mods from their Modrinth projects
+func (u mrUpdater) ResolveLicenses(ctx context.Context, mods []core.Mod) ([]string, error) {
+	licenses := make([]string, len(mods))
+	for i, mod := range mods {
+		rawData, ok := mod.GetParsedUpdateData("modrinth")
+		if !ok {
+			continue
+		}
+		modData, err := fetchMod(ctx, rawData.(mrUpdateData).ModID)
+		if err != nil {
+			if errors.Is(err, errProjectRemoved) {
+				// The license of a removed project can't be found
+				continue
+			}
+			return nil, err
+		}
+		switch modData.License.ID {
+		case "":
+		case "arr":
+			licenses[i] = "LicenseRef-All-Rights-Reserved"
+		case "custom":
+			licenses[i] = "LicenseRef-Custom"
+		default:
+			licenses[i] = modData.License.ID
+		}
+	}
+	return licenses, nil
+}
+
+// removedWarning is shown for mods whose project has been removed from Modrinth
+const removedWarning = "the project has been removed from Modrinth, so it can no longer be updated"
+
+// GetStatus reports mods whose project has been removed from Modrinth
+func (u mrUpdater) GetStatus(mod core.Mod) string {
+	rawData, ok := mod.GetParsedUpdateData("modrinth")
+	if !ok {
+		return ""
+	}
+	if rawData.(mrUpdateData).Removed {
+		return removedWarning
+	}
+	return ""
+}
+
 type cachedStateStore struct {
 	ModID   string
 	Version Version
+	// Removed is true if the mod should only be marked as removed from Modrinth, keeping the installed version
+	Removed bool
+}
+
+func (u mrUpdater) CheckUpdate(ctx context.Context, mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+	return checkLatestVersions(ctx, mods, pack, true)
+}
+
+// SelectFiles finds the latest compatible version of each mod, whether or not it is the installed version
+func (u mrUpdater) SelectFiles(ctx context.Context, mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+	return checkLatestVersions(ctx, mods, pack, false)
 }
 
-func (u mrUpdater) CheckUpdate(mods []core.Mod, mcVersion string, pack core.Pack) ([]core.UpdateCheck, error) {
+// checkLatestVersions finds the latest compatible version of each mod; if onlyNewer is true, the installed version is
+// not returned as an update
+func checkLatestVersions(ctx context.Context, mods []core.Mod, pack core.Pack, onlyNewer bool) ([]core.UpdateCheck, error) {
 	results := make([]core.UpdateCheck, len(mods))
 
 	for i, mod := range mods {
@@ -43,8 +103,19 @@ func (u mrUpdater) CheckUpdate(mods []core.Mod, mcVersion string, pack core.Pack
 
 		data := rawData.(mrUpdateData)
 
-		newVersion, err := getLatestVersion(data.ModID, pack)
+		newVersion, err := getLatestVersion(ctx, data.ModID, pack)
 		if err != nil {
+			if errors.Is(err, errProjectRemoved) {
+				// Keep the installed version, as it may still be downloadable, but mark the mod as removed in its
+				// metadata if it isn't already
+				results[i] = core.UpdateCheck{Warning: removedWarning}
+				if !data.Removed {
+					results[i].UpdateAvailable = true
+					results[i].UpdateString = mod.FileName + " (marking as removed from Modrinth)"
+					results[i].CachedState = cachedStateStore{ModID: data.ModID, Removed: true}
+				}
+				continue
+			}
 			results[i] = core.UpdateCheck{Error: err}
 			continue
 		}
@@ -54,7 +125,8 @@ func (u mrUpdater) CheckUpdate(mods []core.Mod, mcVersion string, pack core.Pack
 			continue
 		}
 
-		if newVersion.ID == data.InstalledVersion { //The latest version from the site is the same as the installed one
+		// A mod marked as removed that is available again is updated to remove the mark
+		if onlyNewer && newVersion.ID == data.InstalledVersion && !data.Removed { //The latest version from the site is the same as the installed one
 			results[i] = core.UpdateCheck{UpdateAvailable: false}
 			continue
 		}
@@ -75,16 +147,20 @@ func (u mrUpdater) CheckUpdate(mods []core.Mod, mcVersion string, pack core.Pack
 		results[i] = core.UpdateCheck{
 			UpdateAvailable: true,
 			UpdateString:    mod.FileName + " -> " + newFilename,
-			CachedState:     cachedStateStore{data.ModID, newVersion},
+			CachedState:     cachedStateStore{ModID: data.ModID, Version: newVersion},
 		}
 	}
 
 	return results, nil
 }
 
-func (u mrUpdater) DoUpdate(mods []*core.Mod, cachedState []interface{}) error {
+func (u mrUpdater) DoUpdate(ctx context.Context, mods []*core.Mod, cachedState []interface{}) error {
 	for i, mod := range mods {
 		modState := cachedState[i].(cachedStateStore)
+		if modState.Removed {
+			mod.Update["modrinth"]["removed"] = true
+			continue
+		}
 		var version = modState.Version
 
 		var file = version.Files[0]
@@ -107,6 +183,7 @@ func (u mrUpdater) DoUpdate(mods []*core.Mod, cachedState []interface{}) error {
 			Hash:       hash,
 		}
 		mod.Update["modrinth"]["version"] = version.ID
+		delete(mod.Update["modrinth"], "removed")
 	}
 
 	return nil
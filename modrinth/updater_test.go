@@ -0,0 +1,110 @@
+package modrinth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/packwiz/packwiz/core"
+	"github.com/spf13/viper"
+)
+
+// loadTestMod writes mod metadata with the given Modrinth update section and loads it
+func loadTestMod(t *testing.T, name string, update string) core.Mod {
+	t.Helper()
+	modFile := filepath.Join(t.TempDir(), name+core.ModExtension)
+	metadata := "name = \"" + name + "\"\nfilename = \"" + name + "-1.0.jar\"\n\n[download]\nurl = \"https://cdn.modrinth.com/" + name + "-1.0.jar\"\nhash-format = \"sha1\"\nhash = \"old\"\n\n[update.modrinth]\n" + update
+	if err := ioutil.WriteFile(modFile, []byte(metadata), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mod, err := core.LoadMod(modFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mod
+}
+
+func TestUpdateRemovedProject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/mod/gone/version":
+			w.WriteHeader(http.StatusGone)
+			_, _ = w.Write([]byte(`{"error":"gone"}`))
+		case "/mod/ok/version":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode([]Version{{
+				ID:            "ok-2",
+				VersionNumber: "2.0",
+				DatePublished: "2021-06-01T00:00:00Z",
+				Files: []VersionFile{{
+					Hashes:   map[string]string{"sha1": "new"},
+					Url:      "https://cdn.modrinth.com/ok-2.0.jar",
+					Filename: "ok-2.0.jar",
+					Primary:  true,
+				}},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	viper.Set("modrinth.api-url", server.URL)
+	defer viper.Set("modrinth.api-url", "")
+
+	pack := core.Pack{Versions: map[string]string{"minecraft": "1.18.2"}}
+	mods := []core.Mod{
+		loadTestMod(t, "gone", "mod-id = \"gone\"\nversion = \"gone-1\"\n"),
+		loadTestMod(t, "ok", "mod-id = \"ok\"\nversion = \"ok-1\"\n"),
+	}
+	u := mrUpdater{}
+
+	checks, err := u.CheckUpdate(context.Background(), mods, "1.18.2", pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, check := range checks {
+		if check.Error != nil {
+			t.Fatalf("CheckUpdate() returned an error for %s: %v", mods[i].Name, check.Error)
+		}
+		if !check.UpdateAvailable {
+			t.Fatalf("CheckUpdate() returned no update for %s", mods[i].Name)
+		}
+	}
+	if checks[0].Warning == "" {
+		t.Error("CheckUpdate() didn't warn that the project was removed")
+	}
+
+	modPtrs := []*core.Mod{&mods[0], &mods[1]}
+	if err := u.DoUpdate(context.Background(), modPtrs, []interface{}{checks[0].CachedState, checks[1].CachedState}); err != nil {
+		t.Fatal(err)
+	}
+	if mods[0].Update["modrinth"]["removed"] != true || mods[0].FileName != "gone-1.0.jar" {
+		t.Errorf("DoUpdate() changed the removed mod to %s, %v", mods[0].FileName, mods[0].Update["modrinth"])
+	}
+	if mods[1].FileName != "ok-2.0.jar" || mods[1].Update["modrinth"]["version"] != "ok-2" {
+		t.Errorf("DoUpdate() didn't update the other mod: %s, %v", mods[1].FileName, mods[1].Update["modrinth"])
+	}
+
+	// The mark is kept in the metadata, so the removed mod is reported without another update
+	if _, _, err := mods[0].Write(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := core.LoadMod(mods[0].GetFilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.GetStatus(reloaded); got != removedWarning {
+		t.Errorf("GetStatus() = %q, want %q", got, removedWarning)
+	}
+	checks, err = u.CheckUpdate(context.Background(), []core.Mod{reloaded}, "1.18.2", pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checks[0].UpdateAvailable || checks[0].Warning == "" {
+		t.Errorf("CheckUpdate() for a marked mod = %+v, want a warning without an update", checks[0])
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/packwiz/packwiz/core"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -27,32 +28,32 @@ Please note that the completions may be incomplete or broken, see https://github
 			if viper.GetBool("utils.completion.source") {
 				err := cmd.Root().GenBashCompletion(os.Stdout)
 				if err != nil {
-					fmt.Printf("Error generating completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error generating completion file: %s\n", err)
 					os.Exit(1)
 				}
 			} else {
 				file, err := getConfigPath("completion.sh")
 				if err != nil {
-					fmt.Printf("Error saving completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error saving completion file: %s\n", err)
 					os.Exit(1)
 				}
 				err = cmd.Root().GenBashCompletionFile(file)
 				if err != nil {
-					fmt.Printf("Error saving completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error saving completion file: %s\n", err)
 					os.Exit(1)
 				}
 
 				// Get the value of $HOME (changed from os.UserHomeDir() for Cygwin/MSYS2 support)
 				home := os.Getenv("HOME")
 				if home == "" {
-					fmt.Printf("Failed to get $HOME location")
+					fmt.Fprintf(os.Stderr, "Failed to get $HOME location")
 					os.Exit(1)
 				}
 				bashrc := filepath.Join(home, ".bashrc")
 
 				absFile, err := filepath.Abs(file)
 				if err != nil {
-					fmt.Printf("Failed to resolve path: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Failed to resolve path: %s\n", err)
 					os.Exit(1)
 				}
 
@@ -64,8 +65,8 @@ Please note that the completions may be incomplete or broken, see https://github
 					cmd.Stdout = &out
 					err := cmd.Run()
 					if err != nil {
-						fmt.Printf("Failed to convert path to POSIX path: %s\n", err)
-						fmt.Println("Ensure you are running this command in the Cygwin/MSYS2 shell (and cygpath is on the PATH)")
+						fmt.Fprintf(os.Stderr, "Failed to convert path to POSIX path: %s\n", err)
+						fmt.Fprintln(os.Stderr, "Ensure you are running this command in the Cygwin/MSYS2 shell (and cygpath is on the PATH)")
 						os.Exit(1)
 					}
 					absFile = out.String()
@@ -82,59 +83,59 @@ Please note that the completions may be incomplete or broken, see https://github
 					// Append to bashrc
 					err = os.MkdirAll(filepath.Dir(bashrc), os.ModePerm)
 					if err != nil {
-						fmt.Printf("Failed to make folder for bashrc: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to make folder for bashrc: %s\n", err)
 						os.Exit(1)
 					}
 					f, err := os.OpenFile(bashrc, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 					if err != nil {
-						fmt.Printf("Failed to open bashrc: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to open bashrc: %s\n", err)
 						os.Exit(1)
 					}
 					_, err = f.WriteString("\n" + command + "\n")
 					if err != nil {
-						fmt.Printf("Failed to write to bashrc: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to write to bashrc: %s\n", err)
 						_ = f.Close()
 						os.Exit(1)
 					}
 					err = f.Close()
 					if err != nil {
-						fmt.Printf("Failed to write to bashrc: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to write to bashrc: %s\n", err)
 						os.Exit(1)
 					}
-					fmt.Println("Completions installed! Restart your shell to load them.")
+					fmt.Fprintln(core.Output, "Completions installed! Restart your shell to load them.")
 				} else {
-					fmt.Println("Completions already installed!")
+					fmt.Fprintln(core.Output, "Completions already installed!")
 				}
 			}
 		} else if args[0] == "powershell" {
 			if viper.GetBool("utils.completion.source") {
 				err := cmd.Root().GenPowerShellCompletion(os.Stdout)
 				if err != nil {
-					fmt.Printf("Error generating completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error generating completion file: %s\n", err)
 					os.Exit(1)
 				}
 			} else {
 				file, err := getConfigPath("completion.ps1")
 				if err != nil {
-					fmt.Printf("Error saving completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error saving completion file: %s\n", err)
 					os.Exit(1)
 				}
 				err = cmd.Root().GenPowerShellCompletionFile(file)
 				if err != nil {
-					fmt.Printf("Error saving completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error saving completion file: %s\n", err)
 					os.Exit(1)
 				}
 
 				// Get the value of $PROFILE (not an environment variable!!)
 				profile, err := getPowershellProfile()
 				if err != nil {
-					fmt.Printf("Failed to get profile location: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Failed to get profile location: %s\n", err)
 					os.Exit(1)
 				}
 
 				absFile, err := filepath.Abs(file)
 				if err != nil {
-					fmt.Printf("Failed to resolve path: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Failed to resolve path: %s\n", err)
 					os.Exit(1)
 				}
 				command := ". " + absFile
@@ -148,50 +149,50 @@ Please note that the completions may be incomplete or broken, see https://github
 					// Append to profile
 					err = os.MkdirAll(filepath.Dir(profile), os.ModePerm)
 					if err != nil {
-						fmt.Printf("Failed to make folder for profile: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to make folder for profile: %s\n", err)
 						os.Exit(1)
 					}
 					f, err := os.OpenFile(profile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 					if err != nil {
-						fmt.Printf("Failed to open profile: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to open profile: %s\n", err)
 						os.Exit(1)
 					}
 					_, err = f.WriteString("\r\n" + command + "\r\n")
 					if err != nil {
-						fmt.Printf("Failed to write to profile: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to write to profile: %s\n", err)
 						_ = f.Close()
 						os.Exit(1)
 					}
 					err = f.Close()
 					if err != nil {
-						fmt.Printf("Failed to write to profile: %s\n", err)
+						fmt.Fprintf(os.Stderr, "Failed to write to profile: %s\n", err)
 						os.Exit(1)
 					}
 				}
-				fmt.Println("Completions installed! Restart your shell to load them.")
+				fmt.Fprintln(core.Output, "Completions installed! Restart your shell to load them.")
 			}
 		} else if args[0] == "zsh" {
 			if viper.GetBool("utils.completion.source") {
 				err := cmd.Root().GenZshCompletion(os.Stdout)
 				if err != nil {
-					fmt.Printf("Error generating completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error generating completion file: %s\n", err)
 					os.Exit(1)
 				}
 			} else {
 				file, err := getConfigPath("completion.zsh")
 				if err != nil {
-					fmt.Printf("Error saving completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error saving completion file: %s\n", err)
 					os.Exit(1)
 				}
 				err = cmd.Root().GenZshCompletionFile(file)
 				if err != nil {
-					fmt.Printf("Error saving completion file: %s\n", err)
+					fmt.Fprintf(os.Stderr, "Error saving completion file: %s\n", err)
 					os.Exit(1)
 				}
 
 				// If someone knows how to do this automagically, please PR it!!
-				fmt.Println("Completions saved to " + file)
-				fmt.Println("You need to put this file in your $fpath manually!")
+				fmt.Fprintln(core.Output, "Completions saved to "+file)
+				fmt.Fprintln(core.Output, "You need to put this file in your $fpath manually!")
 			}
 		}
 	},
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/packwiz/packwiz/core"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
 	"github.com/spf13/viper"
@@ -19,16 +20,16 @@ var markdownCmd = &cobra.Command{
 		outDir := viper.GetString("utils.markdown.dir")
 		err := os.MkdirAll(outDir, os.ModePerm)
 		if err != nil {
-			fmt.Printf("Error creating directory: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error creating directory: %s\n", err)
 			os.Exit(1)
 		}
 		cmd.DisableAutoGenTag = true
 		err = doc.GenMarkdownTree(cmd.Root(), outDir)
 		if err != nil {
-			fmt.Printf("Error generating markdown: %s\n", err)
+			fmt.Fprintf(os.Stderr, "Error generating markdown: %s\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("Generated markdown successfully!")
+		fmt.Fprintln(core.Output, "Generated markdown successfully!")
 	},
 }
 